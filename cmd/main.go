@@ -5,7 +5,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"im-system/internal/config"
 	"im-system/internal/handler"
-	"im-system/internal/middle"
 	"im-system/internal/router"
 	"im-system/internal/service"
 )
@@ -37,7 +36,10 @@ func main() {
 	config.InitRedis(cfg)
 
 	// 初始化服务和处理器
-	userService := service.NewUserService()
+	verificationService := service.NewVerificationService()
+	verificationHandler := handler.NewVerificationHandler(verificationService)
+
+	userService := service.NewUserService(verificationService)
 	userHandler := handler.NewUserHandler(userService)
 
 	friendService := service.NewFriendService()
@@ -45,6 +47,7 @@ func main() {
 
 	notificationService := service.NewNotificationService()
 	notificationHandler := handler.NewNotificationHandler(notificationService)
+	go notificationService.StartRetentionJob() // 定期清理过期通知
 
 	friendGroupService := service.NewFriendShipService()
 	friendGroupHandler := handler.NewFriendShipHandler(friendGroupService)
@@ -60,9 +63,17 @@ func main() {
 	webSocketHandler := handler.NewWebSocketHandler(messageService)
 	//go webSocketHandler.StartMessageHandler() // 启动消息处理
 
+	// 初始化管理员处理器
+	adminHandler := handler.NewAdminHandler()
+
 	// 设置路由
 	r := gin.Default()
 
+	// 只信任配置的反向代理，否则客户端可以通过 X-Forwarded-For 伪造IP绕过按IP的登录限流
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		config.Logger.Fatalf("配置可信代理失败: %v", err)
+	}
+
 	// 配置 CORS 中间件
 	r.Use(cors.New(cors.Config{
 		AllowAllOrigins: true,
@@ -70,11 +81,8 @@ func main() {
 		AllowHeaders:    []string{"Origin", "Content-Type", "Authorization", "token"},
 	}))
 
-	// 使用 JWT 中间件
-	r.Use(middle.AuthMiddleware())
-
-	// 注册路由
-	router.RegisterRoutes(r, userHandler, friendHandler, notificationHandler, friendGroupHandler, groupHandler, webSocketHandler, chatSummaryHandler)
+	// 注册路由，鉴权中间件按路由分组挂载
+	router.RegisterRoutes(r, userHandler, verificationHandler, friendHandler, notificationHandler, friendGroupHandler, groupHandler, webSocketHandler, chatSummaryHandler, adminHandler)
 
 	// 启动服务器
 	config.Logger.Infof("HTTP服务器启动在端口%s\n", cfg.Server.HTTPPort)
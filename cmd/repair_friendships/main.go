@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"im-system/internal/config"
+	"im-system/internal/service"
+	"os"
+)
+
+// 修复历史数据中不对称的好友关系
+// 用法: go run ./cmd/repair_friendships -config config/config.yaml -dry-run
+func main() {
+	configPath := flag.String("config", "config/config.yaml", "配置文件路径")
+	dryRun := flag.Bool("dry-run", false, "只统计需要修复的记录，不修改数据")
+	flag.Parse()
+
+	config.InitLogger()
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+		os.Exit(1)
+	}
+	if err := config.InitDB(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "初始化MySQL失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	report, err := service.RepairFriendships(*dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "修复好友关系失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	mode := "已修复"
+	if *dryRun {
+		mode = "需要修复"
+	}
+	fmt.Printf("%s: 重复记录 %d 条，补全对方关系 %d 条，待处理改为已接受 %d 条，因对方拉黑、删除或拒绝改为已删除 %d 条\n",
+		mode, report.Duplicates, report.Restored, report.Accepted, report.Deleted)
+}
@@ -21,11 +21,169 @@ var RedisClient *redis.Client
 // JWTSecret JWT密钥
 var JWTSecret string
 
+// Group 群组相关配置
+var Group GroupConfig
+
+// Notification 通知相关配置
+var Notification NotificationConfig
+
+// Friend 好友相关配置
+var Friend FriendConfig
+
+// Auth 认证相关配置
+var Auth AuthConfig
+
+// Verify 验证码相关配置
+var Verify VerifyConfig
+
+const (
+	defaultGroupMaxMembers    = 500 // 默认的群成员上限
+	defaultGroupMaxAdmins     = 10  // 默认的群管理员上限
+	defaultGroupActiveDays    = 7   // 默认的活跃统计天数
+	defaultGroupStatsCacheTTL = 600 // 默认的群统计缓存时间（秒）
+
+	defaultNotificationPageSize        = 20   // 默认的通知每页条数
+	defaultNotificationRetentionDays   = 30   // 默认的通知保留天数
+	defaultNotificationCleanupInterval = 3600 // 默认的通知清理间隔（秒）
+
+	defaultFriendRequestExpireHours = 168  // 默认的好友请求有效期（小时）
+	defaultFriendRequestRateLimit   = 20   // 默认的时间窗口内最多发送的好友请求数
+	defaultFriendRequestRateWindow  = 3600 // 默认的好友请求限流时间窗口（秒）
+
+	defaultAuthBcryptCost      = 10      // 默认的 bcrypt 计算成本
+	defaultAuthAccessTokenTTL  = 900     // 默认的访问令牌有效期（秒）
+	defaultAuthRefreshTokenTTL = 2592000 // 默认的刷新令牌有效期（秒）
+	defaultAuthUserCacheTTL    = 600     // 默认的用户信息缓存时间（秒）
+
+	defaultAuthLoginMaxFailures   = 5   // 默认的同一账号连续登录失败上限
+	defaultAuthLoginIPMaxFailures = 50  // 默认的同一IP登录失败上限
+	defaultAuthLoginFailureWindow = 900 // 默认的登录失败计数窗口（秒）
+	defaultAuthLoginLockout       = 900 // 默认的登录锁定时间（秒）
+	defaultAuthLoginMaxDelay      = 30  // 默认的登录失败后最长等待时间（秒）
+
+	defaultAuthTOTPIssuer            = "im-system" // 默认的两步验证签发方名称
+	defaultAuthTwoFactorChallengeTTL = 300         // 默认的两步验证挑战有效期（秒）
+	defaultAuthTwoFactorMaxAttempts  = 5           // 默认的两步验证挑战最多校验次数
+
+	defaultVerifyCodeLength     = 6                      // 默认的验证码长度
+	defaultVerifyCodeTTL        = 300                    // 默认的验证码有效期（秒）
+	defaultVerifyResendInterval = 60                     // 默认的验证码重发间隔（秒）
+	defaultVerifyMaxAttempts    = 5                      // 默认的验证码最多校验次数
+	defaultVerifyIPMaxSends     = 20                     // 默认的同一IP在计数窗口内最多发送的验证码数
+	defaultVerifyIPWindow       = 3600                   // 默认的IP发送计数窗口（秒）
+	defaultVerifyDailyMaxSends  = 10                     // 默认的同一手机号或邮箱每天最多发送的验证码数
+	defaultVerifyLogFile        = "log/verify_codes.log" // 默认的验证码日志文件
+)
+
+// VerifyConfig 验证码配置
+type VerifyConfig struct {
+	CodeLength     int        `yaml:"code_length"`     // 验证码长度
+	CodeTTL        int        `yaml:"code_ttl"`        // 验证码有效期（秒）
+	ResendInterval int        `yaml:"resend_interval"` // 同一目标两次发送之间的最小间隔（秒）
+	MaxAttempts    int        `yaml:"max_attempts"`    // 验证码最多校验次数，超过后作废
+	IPMaxSends     int        `yaml:"ip_max_sends"`    // 同一IP在计数窗口内最多发送的验证码数
+	IPWindow       int        `yaml:"ip_window"`       // IP发送计数窗口（秒）
+	DailyMaxSends  int        `yaml:"daily_max_sends"` // 同一手机号或邮箱每天最多发送的验证码数，不区分用途
+	EmailSender    string     `yaml:"email_sender"`    // 邮件发送方式：log 写入日志文件，smtp 通过 SMTP 发送
+	LogFile        string     `yaml:"log_file"`        // log 发送方式写入的文件，本地开发时在这里查看验证码
+	SMTP           SMTPConfig `yaml:"smtp"`            // SMTP 配置，email_sender 为 smtp 时使用
+}
+
+// SMTPConfig SMTP 邮件服务配置
+type SMTPConfig struct {
+	Host     string `yaml:"host"`     // SMTP 服务器地址
+	Port     int    `yaml:"port"`     // SMTP 服务器端口
+	Username string `yaml:"username"` // 登录用户名，为空时不认证
+	Password string `yaml:"password"` // 登录密码或授权码
+	From     string `yaml:"from"`     // 发件人地址
+}
+
+// AuthConfig 认证配置
+type AuthConfig struct {
+	BcryptCost      int `yaml:"bcrypt_cost"`       // bcrypt 计算成本，取值 4~31，越大越安全也越慢
+	AccessTokenTTL  int `yaml:"access_token_ttl"`  // 访问令牌有效期（秒）
+	RefreshTokenTTL int `yaml:"refresh_token_ttl"` // 刷新令牌有效期（秒），会话超过该时间未刷新即失效
+	UserCacheTTL    int `yaml:"user_cache_ttl"`    // 用户信息在 Redis 中的缓存时间（秒），修改用户信息时会立即删除缓存
+
+	LoginMaxFailures   int `yaml:"login_max_failures"`    // 同一账号在计数窗口内登录失败达到该次数后锁定
+	LoginIPMaxFailures int `yaml:"login_ip_max_failures"` // 同一IP在计数窗口内登录失败达到该次数后锁定
+	LoginFailureWindow int `yaml:"login_failure_window"`  // 登录失败计数窗口（秒）
+	LoginLockout       int `yaml:"login_lockout"`         // 锁定时间（秒）
+	LoginMaxDelay      int `yaml:"login_max_delay"`       // 登录失败后需要等待的最长时间（秒），等待时间随失败次数翻倍
+
+	TOTPIssuer            string `yaml:"totp_issuer"`              // 两步验证在身份验证器应用中显示的签发方名称
+	TwoFactorChallengeTTL int    `yaml:"two_factor_challenge_ttl"` // 密码验证通过后等待输入两步验证码的有效期（秒）
+	TwoFactorMaxAttempts  int    `yaml:"two_factor_max_attempts"`  // 每次登录最多可以输错两步验证码的次数
+}
+
+// FriendConfig 好友配置
+type FriendConfig struct {
+	RequestExpireHours int `yaml:"request_expire_hours"` // 待处理好友请求的有效期（小时）
+	RequestRateLimit   int `yaml:"request_rate_limit"`   // 时间窗口内最多发送的好友请求数
+	RequestRateWindow  int `yaml:"request_rate_window"`  // 好友请求限流时间窗口（秒）
+}
+
+// NotificationConfig 通知配置
+type NotificationConfig struct {
+	PageSize        int `yaml:"page_size"`        // 通知列表默认每页条数
+	RetentionDays   int `yaml:"retention_days"`   // 通知的保留天数，超过后被清理，未处理的好友请求除外
+	CleanupInterval int `yaml:"cleanup_interval"` // 清理任务的执行间隔（秒）
+}
+
+// GroupConfig 群组配置
+type GroupConfig struct {
+	DefaultMaxMembers int            `yaml:"default_max_members"` // 默认的群成员上限
+	MaxAdmins         int            `yaml:"max_admins"`          // 群管理员上限
+	Tiers             map[string]int `yaml:"tiers"`               // 群等级对应的成员上限
+	ActiveDays        int            `yaml:"active_days"`         // 统计活跃成员和消息量的天数
+	StatsCacheTTL     int            `yaml:"stats_cache_ttl"`     // 群统计信息缓存时间（秒）
+	LevelThresholds   []LevelRule    `yaml:"level_thresholds"`    // 群成员等级门槛，按等级从低到高排列
+}
+
+// LevelRule 群成员等级门槛，发言数和活跃天数同时达到时升到对应等级
+type LevelRule struct {
+	Level      int `yaml:"level"`       // 等级
+	Messages   int `yaml:"messages"`    // 需要的发言数
+	ActiveDays int `yaml:"active_days"` // 需要的活跃天数
+}
+
+// defaultLevelThresholds 默认的群成员等级门槛
+var defaultLevelThresholds = []LevelRule{
+	{Level: 2, Messages: 50, ActiveDays: 3},
+	{Level: 3, Messages: 200, ActiveDays: 7},
+	{Level: 4, Messages: 500, ActiveDays: 15},
+	{Level: 5, Messages: 1000, ActiveDays: 30},
+}
+
+// LevelOf 根据发言数和活跃天数计算群成员等级，最低为 1 级
+func (g GroupConfig) LevelOf(messages, activeDays int) int {
+	level := 1
+	for _, rule := range g.LevelThresholds {
+		if messages >= rule.Messages && activeDays >= rule.ActiveDays && rule.Level > level {
+			level = rule.Level
+		}
+	}
+	return level
+}
+
+// MaxMembersOf 获取群的成员上限，优先级：单群覆盖值 > 群等级上限 > 默认上限
+func (g GroupConfig) MaxMembersOf(tier string, override int) int {
+	if override > 0 {
+		return override
+	}
+	if limit, ok := g.Tiers[tier]; ok && limit > 0 {
+		return limit
+	}
+	return g.DefaultMaxMembers
+}
+
 // Config 配置结构体
 type Config struct {
 	Server struct {
 		HTTPPort string `yaml:"http_port"`
 		GRPCPort string `yaml:"grpc_port"`
+
+		TrustedProxies []string `yaml:"trusted_proxies"` // 可信的反向代理地址或网段，只有来自这些地址的请求才使用 X-Forwarded-For 获取客户端IP，为空时不信任任何代理
 	} `yaml:"server"`
 	MySQL struct {
 		Host     string `yaml:"host"`
@@ -39,7 +197,12 @@ type Config struct {
 		Password string `yaml:"password"`
 		DB       int    `yaml:"db"`
 	} `yaml:"redis"`
-	JWTSecret string `yaml:"jwt_secret"` // JWT 密钥
+	JWTSecret    string             `yaml:"jwt_secret"`   // JWT 密钥
+	Group        GroupConfig        `yaml:"group"`        // 群组配置
+	Notification NotificationConfig `yaml:"notification"` // 通知配置
+	Friend       FriendConfig       `yaml:"friend"`       // 好友配置
+	Auth         AuthConfig         `yaml:"auth"`         // 认证配置
+	Verify       VerifyConfig       `yaml:"verify"`       // 验证码配置
 }
 
 // LoadConfig 加载配置文件
@@ -55,8 +218,107 @@ func LoadConfig(filePath string) (*Config, error) {
 	if err := decoder.Decode(&config); err != nil {
 		return nil, err
 	}
+	// 设置默认值
+	if config.Group.DefaultMaxMembers <= 0 {
+		config.Group.DefaultMaxMembers = defaultGroupMaxMembers
+	}
+	if config.Group.MaxAdmins <= 0 {
+		config.Group.MaxAdmins = defaultGroupMaxAdmins
+	}
+	if config.Group.ActiveDays <= 0 {
+		config.Group.ActiveDays = defaultGroupActiveDays
+	}
+	if config.Group.StatsCacheTTL <= 0 {
+		config.Group.StatsCacheTTL = defaultGroupStatsCacheTTL
+	}
+	if len(config.Group.LevelThresholds) == 0 {
+		config.Group.LevelThresholds = defaultLevelThresholds
+	}
+	if config.Notification.PageSize <= 0 {
+		config.Notification.PageSize = defaultNotificationPageSize
+	}
+	if config.Notification.RetentionDays <= 0 {
+		config.Notification.RetentionDays = defaultNotificationRetentionDays
+	}
+	if config.Notification.CleanupInterval <= 0 {
+		config.Notification.CleanupInterval = defaultNotificationCleanupInterval
+	}
+	if config.Friend.RequestExpireHours <= 0 {
+		config.Friend.RequestExpireHours = defaultFriendRequestExpireHours
+	}
+	if config.Friend.RequestRateLimit <= 0 {
+		config.Friend.RequestRateLimit = defaultFriendRequestRateLimit
+	}
+	if config.Friend.RequestRateWindow <= 0 {
+		config.Friend.RequestRateWindow = defaultFriendRequestRateWindow
+	}
+	if config.Auth.BcryptCost <= 0 {
+		config.Auth.BcryptCost = defaultAuthBcryptCost
+	}
+	if config.Auth.AccessTokenTTL <= 0 {
+		config.Auth.AccessTokenTTL = defaultAuthAccessTokenTTL
+	}
+	if config.Auth.RefreshTokenTTL <= 0 {
+		config.Auth.RefreshTokenTTL = defaultAuthRefreshTokenTTL
+	}
+	if config.Auth.UserCacheTTL <= 0 {
+		config.Auth.UserCacheTTL = defaultAuthUserCacheTTL
+	}
+	if config.Auth.LoginMaxFailures <= 0 {
+		config.Auth.LoginMaxFailures = defaultAuthLoginMaxFailures
+	}
+	if config.Auth.LoginIPMaxFailures <= 0 {
+		config.Auth.LoginIPMaxFailures = defaultAuthLoginIPMaxFailures
+	}
+	if config.Auth.LoginFailureWindow <= 0 {
+		config.Auth.LoginFailureWindow = defaultAuthLoginFailureWindow
+	}
+	if config.Auth.LoginLockout <= 0 {
+		config.Auth.LoginLockout = defaultAuthLoginLockout
+	}
+	if config.Auth.LoginMaxDelay <= 0 {
+		config.Auth.LoginMaxDelay = defaultAuthLoginMaxDelay
+	}
+	if config.Auth.TOTPIssuer == "" {
+		config.Auth.TOTPIssuer = defaultAuthTOTPIssuer
+	}
+	if config.Auth.TwoFactorChallengeTTL <= 0 {
+		config.Auth.TwoFactorChallengeTTL = defaultAuthTwoFactorChallengeTTL
+	}
+	if config.Auth.TwoFactorMaxAttempts <= 0 {
+		config.Auth.TwoFactorMaxAttempts = defaultAuthTwoFactorMaxAttempts
+	}
+	if config.Verify.CodeLength <= 0 {
+		config.Verify.CodeLength = defaultVerifyCodeLength
+	}
+	if config.Verify.CodeTTL <= 0 {
+		config.Verify.CodeTTL = defaultVerifyCodeTTL
+	}
+	if config.Verify.ResendInterval <= 0 {
+		config.Verify.ResendInterval = defaultVerifyResendInterval
+	}
+	if config.Verify.MaxAttempts <= 0 {
+		config.Verify.MaxAttempts = defaultVerifyMaxAttempts
+	}
+	if config.Verify.IPMaxSends <= 0 {
+		config.Verify.IPMaxSends = defaultVerifyIPMaxSends
+	}
+	if config.Verify.IPWindow <= 0 {
+		config.Verify.IPWindow = defaultVerifyIPWindow
+	}
+	if config.Verify.DailyMaxSends <= 0 {
+		config.Verify.DailyMaxSends = defaultVerifyDailyMaxSends
+	}
+	if config.Verify.LogFile == "" {
+		config.Verify.LogFile = defaultVerifyLogFile
+	}
 	// 全局赋值
 	JWTSecret = config.JWTSecret
+	Group = config.Group
+	Notification = config.Notification
+	Friend = config.Friend
+	Auth = config.Auth
+	Verify = config.Verify
 	return &config, nil
 }
 
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"im-system/internal/config"
+	"im-system/internal/middle"
+	"im-system/internal/model"
+	"im-system/internal/model/dto"
+	"im-system/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler 管理员运维接口，路由挂载在 AdminMiddleware 之后
+type AdminHandler struct{}
+
+func NewAdminHandler() *AdminHandler {
+	return &AdminHandler{}
+}
+
+// RepairFriendships 检查并修复不对称的好友关系
+func (h *AdminHandler) RepairFriendships(c *gin.Context) {
+	var repairDTO dto.RepairFriendshipsDTO
+	if err := c.ShouldBindJSON(&repairDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+	userID, _ := middle.CurrentUserID(c)
+
+	report, err := service.RepairFriendships(repairDTO.DryRun)
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+	config.Logger.Infof("管理员 %d 修复好友关系(dry_run=%t): %+v", userID, repairDTO.DryRun, report)
+
+	model.SendResponse(c, http.StatusOK, model.Success("修复好友关系成功", report))
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 
+	"im-system/internal/middle"
 	"im-system/internal/model"
 	"im-system/internal/module/kimi"
 	"im-system/internal/service"
@@ -48,6 +49,10 @@ func (h *ChatSummaryHandler) HandleChatSummary(c *gin.Context) {
 		})
 		return
 	}
+	// 只能总结当前登录用户自己的聊天记录
+	if userID, ok := middle.CurrentUserID(c); ok {
+		req.UserID = userID
+	}
 
 	// 验证请求参数
 	if err := validateRequest(&req); err != nil {
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"im-system/internal/config"
+	"im-system/internal/model"
+	"im-system/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sendServiceError 发送 service 层返回的错误，没有权限时返回 403，操作过于频繁时返回 429，其余返回 500
+func sendServiceError(c *gin.Context, err error) {
+	config.Logger.Error(err)
+	var forbidden *service.ForbiddenError
+	if errors.As(err, &forbidden) {
+		model.SendResponse(c, http.StatusForbidden, model.Error(err.Error()))
+		return
+	}
+	var tooMany *service.TooManyRequestsError
+	if errors.As(err, &tooMany) {
+		model.SendResponse(c, http.StatusTooManyRequests, model.Error(err.Error()))
+		return
+	}
+	model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+}
@@ -3,10 +3,12 @@ package handler
 import (
 	"im-system/internal/model/dto"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
 	"im-system/internal/config"
+	"im-system/internal/middle"
 	"im-system/internal/model"
 	"im-system/internal/service"
 )
@@ -22,13 +24,13 @@ func NewFriendHandler(friendService *service.FriendService) *FriendHandler {
 // GetUserFriendAllFriends 获取用户的好友
 func (h *FriendHandler) GetUserFriendAllFriends(c *gin.Context) {
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 
-	groupVOs, err := h.friendService.GetFriendGroupsWithMembers(userID.(uint))
+	groupVOs, err := h.friendService.GetFriendGroupsWithMembers(userID)
 	if err != nil {
 		config.Logger.Error(err)
 		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
@@ -41,12 +43,12 @@ func (h *FriendHandler) GetUserFriendAllFriends(c *gin.Context) {
 // GetUserFriendsChat 获取用户的好友聊天
 func (h *FriendHandler) GetUserFriendsChat(c *gin.Context) {
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
-	friends, err := h.friendService.GetUserFriendsChat(userID.(uint))
+	friends, err := h.friendService.GetUserFriendsChat(userID)
 	if err != nil {
 		config.Logger.Error(err)
 		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
@@ -58,14 +60,14 @@ func (h *FriendHandler) GetUserFriendsChat(c *gin.Context) {
 // GetUserFriends 获取用户的好友
 func (h *FriendHandler) GetUserFriends(c *gin.Context) {
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 
 	// 调用service层获取好友列表
-	friends, err := h.friendService.GetUserFriends(userID.(uint))
+	friends, err := h.friendService.GetUserFriends(userID)
 	if err != nil {
 		config.Logger.Error(err)
 		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
@@ -78,14 +80,14 @@ func (h *FriendHandler) GetUserFriends(c *gin.Context) {
 // GetFriendGroups 获取用户的所有好友分组
 func (h *FriendHandler) GetFriendGroups(c *gin.Context) {
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 
 	// 调用service层获取好友分组
-	groups, err := h.friendService.GetUserFriendsGroups(userID.(uint))
+	groups, err := h.friendService.GetUserFriendsGroups(userID)
 	if err != nil {
 		config.Logger.Error(err)
 		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
@@ -98,8 +100,8 @@ func (h *FriendHandler) GetFriendGroups(c *gin.Context) {
 // SearchFriendGroups 搜索好友分组
 func (h *FriendHandler) SearchFriendGroups(c *gin.Context) {
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
@@ -112,7 +114,7 @@ func (h *FriendHandler) SearchFriendGroups(c *gin.Context) {
 	}
 
 	// 调用service层搜索好友分组
-	groups, err := h.friendService.SearchFriendGroups(userID.(uint), keyword)
+	groups, err := h.friendService.SearchFriendGroups(userID, keyword)
 	if err != nil {
 		config.Logger.Error(err)
 		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
@@ -125,8 +127,8 @@ func (h *FriendHandler) SearchFriendGroups(c *gin.Context) {
 // UpdateFriendGroup 更新好友分组
 func (h *FriendHandler) UpdateFriendGroup(c *gin.Context) {
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
@@ -139,7 +141,7 @@ func (h *FriendHandler) UpdateFriendGroup(c *gin.Context) {
 	}
 
 	// 调用service层更新好友分组
-	if err := h.friendService.UpdateFriendGroup(userID.(uint), updateDTO); err != nil {
+	if err := h.friendService.UpdateFriendGroup(userID, updateDTO); err != nil {
 		config.Logger.Error(err)
 		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
 		return
@@ -147,3 +149,49 @@ func (h *FriendHandler) UpdateFriendGroup(c *gin.Context) {
 
 	model.SendResponse(c, http.StatusOK, model.Success("更新好友分组成功", nil))
 }
+
+// GetFriendSuggestions 获取可能认识的人
+func (h *FriendHandler) GetFriendSuggestions(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+	// 推荐人数不合法时使用默认值
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	suggestions, err := h.friendService.GetFriendSuggestions(userID, limit)
+	if err != nil {
+		config.Logger.Error(err)
+		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("获取好友推荐成功", suggestions))
+}
+
+// UpdateFriendSettings 修改好友的个性化设置
+func (h *FriendHandler) UpdateFriendSettings(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	// 解析请求参数
+	var settingsDTO dto.FriendSettingsDTO
+	if err := c.ShouldBindJSON(&settingsDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求参数"))
+		return
+	}
+
+	if err := h.friendService.UpdateFriendSettings(userID, settingsDTO); err != nil {
+		config.Logger.Error(err)
+		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("更新好友设置成功", nil))
+}
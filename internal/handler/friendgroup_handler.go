@@ -5,8 +5,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"im-system/internal/config"
+	"im-system/internal/middle"
 	"im-system/internal/model"
 	"im-system/internal/model/db"
+	"im-system/internal/model/dto"
 	"im-system/internal/service"
 )
 
@@ -21,13 +23,13 @@ func NewFriendShipHandler(friendShipService *service.FriendGroupService) *Friend
 // GetUserFriendGroups 获取当前用户的所有好友分组
 func (h *FriendGroupHandler) GetUserFriendGroups(c *gin.Context) {
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 
-	groups, err := h.friendShipService.GetFriendGroupsWithMembers(userID.(uint))
+	groups, err := h.friendShipService.GetFriendGroupsWithMembers(userID)
 	if err != nil {
 		config.Logger.Error(err)
 		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
@@ -46,13 +48,13 @@ func (h *FriendGroupHandler) CreateFriendGroup(c *gin.Context) {
 	}
 
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 
-	group.UserID = userID.(uint) // 设置分组的用户ID
+	group.UserID = userID // 设置分组的用户ID
 
 	if err := h.friendShipService.CreateFriendGroup(group.UserID, group.GroupName); err != nil {
 		config.Logger.Error(err)
@@ -62,3 +64,99 @@ func (h *FriendGroupHandler) CreateFriendGroup(c *gin.Context) {
 
 	model.SendResponse(c, http.StatusOK, model.Success("好友分组创建成功", nil))
 }
+
+// RenameFriendGroup 重命名好友分组
+func (h *FriendGroupHandler) RenameFriendGroup(c *gin.Context) {
+	var renameDTO dto.RenameFriendGroupDTO
+	if err := c.ShouldBindJSON(&renameDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.friendShipService.RenameFriendGroup(userID, renameDTO.GroupID, renameDTO.GroupName); err != nil {
+		config.Logger.Error(err)
+		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("好友分组重命名成功", nil))
+}
+
+// ReorderFriendGroups 调整好友分组的顺序
+func (h *FriendGroupHandler) ReorderFriendGroups(c *gin.Context) {
+	var reorderDTO dto.ReorderFriendGroupsDTO
+	if err := c.ShouldBindJSON(&reorderDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.friendShipService.ReorderFriendGroups(userID, reorderDTO.GroupIDs); err != nil {
+		config.Logger.Error(err)
+		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("好友分组排序成功", nil))
+}
+
+// DeleteFriendGroup 删除好友分组，分组中的好友移动到默认分组
+func (h *FriendGroupHandler) DeleteFriendGroup(c *gin.Context) {
+	var deleteDTO dto.DeleteFriendGroupDTO
+	if err := c.ShouldBindJSON(&deleteDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.friendShipService.DeleteFriendGroup(userID, deleteDTO.GroupID); err != nil {
+		config.Logger.Error(err)
+		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("好友分组删除成功", nil))
+}
+
+// MoveFriends 批量移动好友到指定分组
+func (h *FriendGroupHandler) MoveFriends(c *gin.Context) {
+	var moveDTO dto.MoveFriendsDTO
+	if err := c.ShouldBindJSON(&moveDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.friendShipService.MoveFriends(userID, moveDTO.GroupID, moveDTO.FriendIDs); err != nil {
+		config.Logger.Error(err)
+		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("好友移动成功", nil))
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"im-system/internal/middle"
+	"im-system/internal/model"
+	"im-system/internal/model/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PublishAnnouncement 发布群公告
+func (h *GroupHandler) PublishAnnouncement(c *gin.Context) {
+	var publishDTO dto.PublishAnnouncementDTO
+	if err := c.ShouldBindJSON(&publishDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	announcement, err := h.groupService.PublishAnnouncement(publishDTO.GroupID, userID, publishDTO.Content, publishDTO.Pinned, publishDTO.RequireAck)
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("发布公告成功", announcement))
+}
+
+// GetAnnouncements 获取群公告历史
+func (h *GroupHandler) GetAnnouncements(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Query("group_id"), 10, 32)
+	if err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的群组ID"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	announcements, err := h.groupService.GetAnnouncements(uint(groupID), userID)
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("获取公告成功", announcements))
+}
+
+// PinAnnouncement 置顶或取消置顶群公告
+func (h *GroupHandler) PinAnnouncement(c *gin.Context) {
+	var pinDTO dto.PinAnnouncementDTO
+	if err := c.ShouldBindJSON(&pinDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.groupService.PinAnnouncement(pinDTO.AnnouncementID, userID, pinDTO.Pinned); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("更新公告置顶状态成功", nil))
+}
+
+// DeleteAnnouncement 删除群公告
+func (h *GroupHandler) DeleteAnnouncement(c *gin.Context) {
+	var deleteDTO dto.AnnouncementIDDTO
+	if err := c.ShouldBindJSON(&deleteDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.groupService.DeleteAnnouncement(deleteDTO.AnnouncementID, userID); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("删除公告成功", nil))
+}
+
+// AcknowledgeAnnouncement 确认已读群公告
+func (h *GroupHandler) AcknowledgeAnnouncement(c *gin.Context) {
+	var ackDTO dto.AnnouncementIDDTO
+	if err := c.ShouldBindJSON(&ackDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.groupService.AcknowledgeAnnouncement(ackDTO.AnnouncementID, userID); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("确认公告成功", nil))
+}
+
+// GetAnnouncementAcks 获取群公告的确认情况
+func (h *GroupHandler) GetAnnouncementAcks(c *gin.Context) {
+	announcementID, err := strconv.ParseUint(c.Query("announcement_id"), 10, 32)
+	if err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的公告ID"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	acks, err := h.groupService.GetAnnouncementAcks(uint(announcementID), userID)
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("获取公告确认情况成功", acks))
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"im-system/internal/middle"
+	"im-system/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuditLogs 分页获取群组审计日志
+func (h *GroupHandler) GetAuditLogs(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Query("group_id"), 10, 32)
+	if err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的群组ID"))
+		return
+	}
+	// 分页参数不合法时使用默认值
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	logs, err := h.groupService.GetAuditLogs(uint(groupID), userID, page, pageSize)
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("获取审计日志成功", logs))
+}
@@ -3,8 +3,11 @@ package handler
 import (
 	"im-system/internal/model/db"
 	"net/http"
+	"strconv"
+	"time"
 
 	"im-system/internal/config"
+	"im-system/internal/middle"
 	"im-system/internal/model"
 	"im-system/internal/model/dto"
 	"im-system/internal/service"
@@ -29,19 +32,13 @@ func (h *GroupHandler) CreateGroup(c *gin.Context) {
 	}
 
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 	// 从上下文中获取用户信息
-	user, exists := c.Get("user_info")
-	if !exists {
-		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
-		return
-	}
-	// 将用户信息转换为User结构体
-	userInfo, ok := user.(*db.User)
+	userInfo, ok := middle.CurrentUserInfo(c)
 	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户信息错误"))
 		return
@@ -49,13 +46,13 @@ func (h *GroupHandler) CreateGroup(c *gin.Context) {
 
 	group := db.Group{
 		Name:        createGroupDTO.Name,
-		OwnerID:     userID.(uint), // 设置群主的用户ID
+		OwnerID:     userID, // 设置群主的用户ID
 		GroupAvatar: createGroupDTO.GroupAvatar,
+		Category:    createGroupDTO.Category,
 	}
 
 	if err := h.groupService.CreateGroup(group, *userInfo); err != nil {
-		config.Logger.Error(err)
-		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		sendServiceError(c, err)
 		return
 	}
 
@@ -72,8 +69,7 @@ func (h *GroupHandler) QueryGroups(c *gin.Context) {
 	// todo: 参数校验
 	groups, err := h.groupService.QueryGroups(queryGroupDTO.GroupID, queryGroupDTO.GroupName)
 	if err != nil {
-		config.Logger.Error(err)
-		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		sendServiceError(c, err)
 		return
 	}
 
@@ -83,16 +79,15 @@ func (h *GroupHandler) QueryGroups(c *gin.Context) {
 // GetUserGroups 获取用户所在的群聊
 func (h *GroupHandler) GetUserGroups(c *gin.Context) {
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 
-	groups, err := h.groupService.GetUserGroups(userID.(uint))
+	groups, err := h.groupService.GetUserGroups(userID)
 	if err != nil {
-		config.Logger.Error(err)
-		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		sendServiceError(c, err)
 		return
 	}
 
@@ -102,12 +97,12 @@ func (h *GroupHandler) GetUserGroups(c *gin.Context) {
 // GetMyAllGroups 获取用户的所有群聊
 func (h *GroupHandler) GetMyAllGroups(c *gin.Context) {
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
-	groups, err := h.groupService.GetMyAllGroups(userID.(uint))
+	groups, err := h.groupService.GetMyAllGroups(userID)
 
 	if err != nil {
 		config.Logger.Error(err)
@@ -143,16 +138,15 @@ func (h *GroupHandler) InviteGroup(c *gin.Context) {
 	}
 
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 
 	// 调用service层处理邀请逻辑
-	if err := h.groupService.InviteGroup(userID.(uint), inviteGroupDTO.GroupID, inviteGroupDTO.FriendIDs); err != nil {
-		config.Logger.Error(err)
-		model.SendResponse(c, http.StatusInternalServerError, model.Error("邀请失败"))
+	if err := h.groupService.InviteGroup(userID, inviteGroupDTO.GroupID, inviteGroupDTO.FriendIDs); err != nil {
+		sendServiceError(c, err)
 		return
 	}
 
@@ -173,40 +167,15 @@ func (h *GroupHandler) UpdateMemberRole(c *gin.Context) {
 	}
 
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 
-	// 检查当前用户是否有权限修改成员角色
-	var currentMember db.GroupMember
-	if err := h.groupService.GetDB().Where("group_id = ? AND user_id = ?", updateRoleDTO.GroupID, userID).First(&currentMember).Error; err != nil {
-		model.SendResponse(c, http.StatusForbidden, model.Error("您不是该群组成员"))
-		return
-	}
-
-	// 只有群主和管理员可以修改成员角色
-	if currentMember.Role != "owner" && currentMember.Role != "admin" {
-		model.SendResponse(c, http.StatusForbidden, model.Error("您没有权限修改成员角色"))
-		return
-	}
-
-	// 管理员不能修改群主角色
-	if currentMember.Role == "admin" {
-		var targetMember db.GroupMember
-		if err := h.groupService.GetDB().Where("group_id = ? AND user_id = ?", updateRoleDTO.GroupID, updateRoleDTO.MemberID).First(&targetMember).Error; err == nil {
-			if targetMember.Role == "owner" {
-				model.SendResponse(c, http.StatusForbidden, model.Error("管理员不能修改群主角色"))
-				return
-			}
-		}
-	}
-
-	// 更新成员角色
-	if err := h.groupService.UpdateMemberRole(updateRoleDTO.GroupID, updateRoleDTO.MemberID, updateRoleDTO.Role); err != nil {
-		config.Logger.Error(err)
-		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+	// 更新成员角色，权限由service层校验
+	if err := h.groupService.UpdateMemberRole(updateRoleDTO.GroupID, userID, updateRoleDTO.MemberID, updateRoleDTO.Role); err != nil {
+		sendServiceError(c, err)
 		return
 	}
 
@@ -226,40 +195,15 @@ func (h *GroupHandler) RemoveMember(c *gin.Context) {
 	}
 
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 
-	// 检查当前用户是否有权限移除成员
-	var currentMember db.GroupMember
-	if err := h.groupService.GetDB().Where("group_id = ? AND user_id = ?", removeMemberDTO.GroupID, userID).First(&currentMember).Error; err != nil {
-		model.SendResponse(c, http.StatusForbidden, model.Error("您不是该群组成员"))
-		return
-	}
-
-	// 只有群主和管理员可以移除成员
-	if currentMember.Role != "owner" && currentMember.Role != "admin" {
-		model.SendResponse(c, http.StatusForbidden, model.Error("您没有权限移除成员"))
-		return
-	}
-
-	// 管理员不能移除其他管理员
-	if currentMember.Role == "admin" {
-		var targetMember db.GroupMember
-		if err := h.groupService.GetDB().Where("group_id = ? AND user_id = ?", removeMemberDTO.GroupID, removeMemberDTO.MemberID).First(&targetMember).Error; err == nil {
-			if targetMember.Role == "admin" {
-				model.SendResponse(c, http.StatusForbidden, model.Error("管理员不能移除其他管理员"))
-				return
-			}
-		}
-	}
-
-	// 移除成员
-	if err := h.groupService.RemoveMember(removeMemberDTO.GroupID, removeMemberDTO.MemberID); err != nil {
-		config.Logger.Error(err)
-		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+	// 移除成员，权限由service层校验
+	if err := h.groupService.RemoveMember(removeMemberDTO.GroupID, userID, removeMemberDTO.MemberID); err != nil {
+		sendServiceError(c, err)
 		return
 	}
 
@@ -278,16 +222,15 @@ func (h *GroupHandler) QuitGroup(c *gin.Context) {
 	}
 
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 
 	// 调用service层处理退出群聊逻辑
-	if err := h.groupService.QuitGroup(quitGroupDTO.GroupID, userID.(uint)); err != nil {
-		config.Logger.Error(err)
-		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+	if err := h.groupService.QuitGroup(quitGroupDTO.GroupID, userID); err != nil {
+		sendServiceError(c, err)
 		return
 	}
 
@@ -302,6 +245,7 @@ func (h *GroupHandler) UpdateGroup(c *gin.Context) {
 		GroupAvatar  string `json:"group_avatar"`
 		Announcement string `json:"announcement"`
 		Description  string `json:"description"`
+		Category     string `json:"category"`
 	}
 
 	if err := c.ShouldBindJSON(&updateGroupDTO); err != nil {
@@ -310,8 +254,8 @@ func (h *GroupHandler) UpdateGroup(c *gin.Context) {
 	}
 
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
@@ -322,19 +266,162 @@ func (h *GroupHandler) UpdateGroup(c *gin.Context) {
 		GroupAvatar  string `json:"group_avatar"`
 		Announcement string `json:"announcement"`
 		Description  string `json:"description"`
+		Category     string `json:"category"`
 	}{
 		Name:         updateGroupDTO.Name,
 		GroupAvatar:  updateGroupDTO.GroupAvatar,
 		Announcement: updateGroupDTO.Announcement,
 		Description:  updateGroupDTO.Description,
+		Category:     updateGroupDTO.Category,
 	}
 
 	// 调用service层处理更新群聊信息
-	if err := h.groupService.UpdateGroup(updateGroupDTO.GroupID, userID.(uint), updateData); err != nil {
-		config.Logger.Error(err)
-		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+	if err := h.groupService.UpdateGroup(updateGroupDTO.GroupID, userID, updateData); err != nil {
+		sendServiceError(c, err)
 		return
 	}
 
 	model.SendResponse(c, http.StatusOK, model.Success("更新群聊信息成功", nil))
 }
+
+// CreateInviteLink 创建群邀请链接
+func (h *GroupHandler) CreateInviteLink(c *gin.Context) {
+	var createLinkDTO dto.CreateGroupInviteLinkDTO
+	if err := c.ShouldBindJSON(&createLinkDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	expiresIn := time.Duration(createLinkDTO.ExpiresIn) * time.Second
+	link, err := h.groupService.CreateInviteLink(userID, createLinkDTO.GroupID, expiresIn, createLinkDTO.MaxUses)
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("创建邀请链接成功", link))
+}
+
+// GetInviteLinks 获取群聊的邀请链接
+func (h *GroupHandler) GetInviteLinks(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Query("group_id"), 10, 32)
+	if err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的群组ID"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	links, err := h.groupService.GetInviteLinks(userID, uint(groupID))
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("获取邀请链接成功", links))
+}
+
+// RevokeInviteLink 撤销群邀请链接
+func (h *GroupHandler) RevokeInviteLink(c *gin.Context) {
+	var revokeLinkDTO dto.RevokeGroupInviteLinkDTO
+	if err := c.ShouldBindJSON(&revokeLinkDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.groupService.RevokeInviteLink(userID, revokeLinkDTO.LinkID); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("撤销邀请链接成功", nil))
+}
+
+// JoinGroupByLink 通过邀请码加入群聊
+func (h *GroupHandler) JoinGroupByLink(c *gin.Context) {
+	var joinDTO dto.JoinGroupByLinkDTO
+	if err := c.ShouldBindJSON(&joinDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	group, err := h.groupService.JoinGroupByLink(userID, joinDTO.Code)
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("加入群聊成功", group))
+}
+
+// SetMemberNickname 修改自己在群中的昵称
+func (h *GroupHandler) SetMemberNickname(c *gin.Context) {
+	var nicknameDTO dto.SetGroupNicknameDTO
+	if err := c.ShouldBindJSON(&nicknameDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.groupService.SetMemberNickname(nicknameDTO.GroupID, userID, nicknameDTO.Nickname); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("修改群昵称成功", nil))
+}
+
+// SetMemberTitle 授予群成员称号
+func (h *GroupHandler) SetMemberTitle(c *gin.Context) {
+	var titleDTO dto.SetGroupTitleDTO
+	if err := c.ShouldBindJSON(&titleDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.groupService.SetMemberTitle(titleDTO.GroupID, userID, titleDTO.MemberID, titleDTO.Title); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("授予群称号成功", nil))
+}
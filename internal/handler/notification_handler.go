@@ -6,7 +6,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"im-system/internal/config"
+	"im-system/internal/middle"
 	"im-system/internal/model"
+	"im-system/internal/model/dto"
 	"im-system/internal/service"
 )
 
@@ -18,19 +20,22 @@ func NewNotificationHandler(notificationService *service.NotificationService) *N
 	return &NotificationHandler{notificationService: notificationService}
 }
 
-// GetNotifications 获取用户的通知
+// GetNotifications 分页获取用户的通知，支持按类型、状态和已读状态过滤
 func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	var queryDTO dto.QueryNotificationsDTO
+	if err := c.ShouldBindQuery(&queryDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 
-	// 获取 Type 参数
-	notificationType := c.Query("type")
-
-	notifications, err := h.notificationService.GetNotifications(userID.(uint), notificationType)
+	notifications, err := h.notificationService.GetNotifications(userID, queryDTO)
 	if err != nil {
 		config.Logger.Error(err)
 		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
@@ -60,17 +65,21 @@ func (h *NotificationHandler) HandleFriendRequest(c *gin.Context) {
 		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的通知群组ID"))
 		return
 	}
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
 	if action == "accept" {
-		if err := h.notificationService.AcceptFriendRequest(uint(notificationID), uint(groupId)); err != nil {
-			config.Logger.Error(err)
-			model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		if err := h.notificationService.AcceptFriendRequest(userID, uint(notificationID), uint(groupId)); err != nil {
+			sendServiceError(c, err)
 			return
 		}
 		model.SendResponse(c, http.StatusOK, model.Success("好友请求已接受", nil))
 	} else if action == "reject" {
-		if err := h.notificationService.RejectFriendRequest(uint(notificationID)); err != nil {
-			config.Logger.Error(err)
-			model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		if err := h.notificationService.RejectFriendRequest(userID, uint(notificationID)); err != nil {
+			sendServiceError(c, err)
 			return
 		}
 		model.SendResponse(c, http.StatusOK, model.Success("好友请求已拒绝", nil))
@@ -82,13 +91,13 @@ func (h *NotificationHandler) HandleFriendRequest(c *gin.Context) {
 // GetSentNotifications 获取用户发出的所有通知请求
 func (h *NotificationHandler) GetSentNotifications(c *gin.Context) {
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 
-	notifications, err := h.notificationService.GetSentNotifications(userID.(uint))
+	notifications, err := h.notificationService.GetSentNotifications(userID)
 	if err != nil {
 		config.Logger.Error(err)
 		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
@@ -101,13 +110,13 @@ func (h *NotificationHandler) GetSentNotifications(c *gin.Context) {
 // GetFriendRequestNotifications 获取特定用户的所有好友请求通知
 func (h *NotificationHandler) GetFriendRequestNotifications(c *gin.Context) {
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 
-	notifications, err := h.notificationService.GetFriendRequestNotifications(userID.(uint))
+	notifications, err := h.notificationService.GetFriendRequestNotifications(userID)
 	if err != nil {
 		config.Logger.Error(err)
 		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
@@ -116,3 +125,106 @@ func (h *NotificationHandler) GetFriendRequestNotifications(c *gin.Context) {
 
 	model.SendResponse(c, http.StatusOK, model.Success("获取好友请求通知成功", notifications))
 }
+
+// GetUnreadCount 获取未读通知数
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	unread, err := h.notificationService.GetUnreadCount(userID)
+	if err != nil {
+		config.Logger.Error(err)
+		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("获取未读通知数成功", unread))
+}
+
+// MarkAsRead 将指定通知标记为已读
+func (h *NotificationHandler) MarkAsRead(c *gin.Context) {
+	var markReadDTO dto.NotificationIDsDTO
+	if err := c.ShouldBindJSON(&markReadDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.notificationService.MarkAsRead(userID, markReadDTO.NotificationIDs); err != nil {
+		config.Logger.Error(err)
+		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("标记已读成功", nil))
+}
+
+// MarkAllAsRead 将所有通知标记为已读
+func (h *NotificationHandler) MarkAllAsRead(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.notificationService.MarkAllAsRead(userID); err != nil {
+		config.Logger.Error(err)
+		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("全部标记已读成功", nil))
+}
+
+// DeleteNotifications 删除指定通知
+func (h *NotificationHandler) DeleteNotifications(c *gin.Context) {
+	var deleteDTO dto.NotificationIDsDTO
+	if err := c.ShouldBindJSON(&deleteDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.notificationService.DeleteNotifications(userID, deleteDTO.NotificationIDs); err != nil {
+		config.Logger.Error(err)
+		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("删除通知成功", nil))
+}
+
+// ClearNotifications 清空已处理的通知
+func (h *NotificationHandler) ClearNotifications(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.notificationService.ClearNotifications(userID); err != nil {
+		config.Logger.Error(err)
+		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("清空通知成功", nil))
+}
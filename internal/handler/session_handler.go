@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"im-system/internal/config"
+	"im-system/internal/middle"
+	"im-system/internal/model"
+	"im-system/internal/model/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RefreshToken 使用刷新令牌换取新的令牌
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var refreshDTO dto.RefreshTokenDTO
+	if err := c.ShouldBindJSON(&refreshDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	tokens, err := h.userService.RefreshToken(refreshDTO.RefreshToken, c.ClientIP())
+	if err != nil {
+		config.Logger.Error(err)
+		model.SendResponse(c, http.StatusUnauthorized, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("刷新令牌成功", tokens))
+}
+
+// GetSessions 获取当前用户所有设备上的会话
+func (h *UserHandler) GetSessions(c *gin.Context) {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	sessions, err := h.userService.GetSessions(userID, middle.CurrentSessionID(c))
+	if err != nil {
+		config.Logger.Error(err)
+		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("获取会话列表成功", sessions))
+}
+
+// RevokeSession 注销指定的会话
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	var revokeDTO dto.RevokeSessionDTO
+	if err := c.ShouldBindJSON(&revokeDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.userService.RevokeSession(userID, revokeDTO.SessionID); err != nil {
+		config.Logger.Error(err)
+		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("注销会话成功", nil))
+}
+
+// RevokeAllSessions 注销当前用户的所有会话
+func (h *UserHandler) RevokeAllSessions(c *gin.Context) {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.userService.RevokeAllSessions(userID); err != nil {
+		config.Logger.Error(err)
+		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("注销所有会话成功", nil))
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"im-system/internal/config"
+	"im-system/internal/middle"
+	"im-system/internal/model"
+	"im-system/internal/model/dto"
+	"im-system/internal/model/vo"
+	"im-system/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EnrollTwoFactor 获取两步验证密钥
+func (h *UserHandler) EnrollTwoFactor(c *gin.Context) {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	enroll, err := h.userService.EnrollTwoFactor(userID)
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("获取两步验证密钥成功", enroll))
+}
+
+// ConfirmTwoFactor 确认开启两步验证
+func (h *UserHandler) ConfirmTwoFactor(c *gin.Context) {
+	var codeDTO dto.TwoFactorCodeDTO
+	if err := c.ShouldBindJSON(&codeDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	codes, err := h.userService.ConfirmTwoFactor(userID, codeDTO.Code)
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("开启两步验证成功，请妥善保存恢复码", vo.RecoveryCodesVO{RecoveryCodes: codes}))
+}
+
+// DisableTwoFactor 关闭两步验证
+func (h *UserHandler) DisableTwoFactor(c *gin.Context) {
+	var disableDTO dto.DisableTwoFactorDTO
+	if err := c.ShouldBindJSON(&disableDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.userService.DisableTwoFactor(userID, disableDTO.Password, disableDTO.Code); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("关闭两步验证成功", nil))
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var codeDTO dto.TwoFactorCodeDTO
+	if err := c.ShouldBindJSON(&codeDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	codes, err := h.userService.RegenerateRecoveryCodes(userID, codeDTO.Code)
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("重新生成恢复码成功", vo.RecoveryCodesVO{RecoveryCodes: codes}))
+}
+
+// LoginTwoFactor 登录第二步，校验两步验证码
+func (h *UserHandler) LoginTwoFactor(c *gin.Context) {
+	var loginDTO dto.LoginTwoFactorDTO
+	if err := c.ShouldBindJSON(&loginDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	tokens, err := h.userService.LoginTwoFactor(loginDTO.ChallengeToken, loginDTO.Code, c.ClientIP())
+	if err != nil {
+		config.Logger.Error(err)
+		var tooMany *service.TooManyRequestsError
+		if errors.As(err, &tooMany) {
+			model.SendResponse(c, http.StatusTooManyRequests, model.Error(err.Error()))
+			return
+		}
+		model.SendResponse(c, http.StatusUnauthorized, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("登录成功", tokens))
+}
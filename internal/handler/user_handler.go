@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"im-system/internal/config"
+	"im-system/internal/middle"
 	"im-system/internal/model"
 	"im-system/internal/model/db"
 	"im-system/internal/model/dto"
@@ -25,13 +27,13 @@ func NewUserHandler(userService *service.UserService) *UserHandler {
 }
 
 func (h *UserHandler) Register(c *gin.Context) {
-	var user db.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var registerDTO dto.RegisterDTO
+	if err := c.ShouldBindJSON(&registerDTO); err != nil {
 		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
 		return
 	}
 
-	if err := h.userService.Register(user); err != nil {
+	if err := h.userService.Register(registerDTO); err != nil {
 		config.Logger.Error(err)
 		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
 		return
@@ -41,36 +43,44 @@ func (h *UserHandler) Register(c *gin.Context) {
 }
 
 func (h *UserHandler) Login(c *gin.Context) {
-	var loginData struct {
-		PhoneNumber string `json:"phone_number"`
-		Password    string `json:"password"`
-	}
-
-	if err := c.ShouldBindJSON(&loginData); err != nil {
+	var loginDTO dto.LoginDTO
+	if err := c.ShouldBindJSON(&loginDTO); err != nil {
 		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
 		return
 	}
+	if loginDTO.DeviceName == "" {
+		loginDTO.DeviceName = c.Request.UserAgent()
+	}
 
-	userID, token, err := h.userService.Login(loginData.PhoneNumber, loginData.Password)
+	tokens, err := h.userService.Login(loginDTO, c.ClientIP())
 	if err != nil {
 		config.Logger.Error(err)
+		var tooMany *service.TooManyRequestsError
+		if errors.As(err, &tooMany) {
+			model.SendResponse(c, http.StatusTooManyRequests, model.Error(err.Error()))
+			return
+		}
 		model.SendResponse(c, http.StatusUnauthorized, model.Error(err.Error()))
 		return
 	}
 
-	model.SendResponse(c, http.StatusOK, model.Success("登录成功", gin.H{"user_id": userID, "token": token}))
+	if tokens.TwoFactorRequired {
+		model.SendResponse(c, http.StatusOK, model.Success("请输入两步验证码", tokens))
+		return
+	}
+	model.SendResponse(c, http.StatusOK, model.Success("登录成功", tokens))
 }
 
 // GetUserInfo 获取用户信息
 func (h *UserHandler) GetUserInfo(c *gin.Context) {
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("未提供用户ID"))
 		return
 	}
 
-	user, err := h.userService.GetUserInfo(userID.(uint))
+	user, err := h.userService.GetUserInfo(userID)
 	if err != nil {
 		config.Logger.Error(err)
 		model.SendResponse(c, http.StatusNotFound, model.Error(err.Error()))
@@ -91,18 +101,23 @@ func (h *UserHandler) AddFriend(c *gin.Context) {
 		model.SendResponse(c, http.StatusBadRequest, model.Error("请选择好友分组"))
 		return
 	}
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
-	addFriendDTO.UserID = userID.(uint)
+	addFriendDTO.UserID = userID
 
-	if err := h.userService.AddFriend(addFriendDTO); err != nil {
+	autoAccepted, err := h.userService.AddFriend(addFriendDTO)
+	if err != nil {
 		config.Logger.Error(err)
 		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
 		return
 	}
+	if autoAccepted {
+		model.SendResponse(c, http.StatusOK, model.Success("对方也向您发送了好友请求，已互相添加为好友", nil))
+		return
+	}
 
 	model.SendResponse(c, http.StatusOK, model.Success("发送好友添加请求", nil))
 }
@@ -110,13 +125,13 @@ func (h *UserHandler) AddFriend(c *gin.Context) {
 // Logout 处理用户退出登录
 func (h *UserHandler) Logout(c *gin.Context) {
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 
-	if err := h.userService.Logout(c, userID.(uint)); err != nil {
+	if err := h.userService.Logout(userID, middle.CurrentSessionID(c)); err != nil {
 		config.Logger.Error(err)
 		model.SendResponse(c, http.StatusInternalServerError, model.Error("退出登录失败"))
 		return
@@ -134,14 +149,14 @@ func (h *UserHandler) UpdateUserInfo(c *gin.Context) {
 	}
 
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 
 	user := db.User{
-		ID:          userID.(uint),
+		ID:          userID,
 		Username:    updateUserDTO.Username,
 		AvatarURL:   updateUserDTO.AvatarURL,
 		DateOfBirth: updateUserDTO.Birthday,
@@ -149,8 +164,11 @@ func (h *UserHandler) UpdateUserInfo(c *gin.Context) {
 		Bio:         updateUserDTO.Bio,
 		Gender:      updateUserDTO.Gender,
 	}
+	if updateUserDTO.Handle != "" {
+		user.Handle = &updateUserDTO.Handle
+	}
 
-	if err := h.userService.UpdateUserInfo(userID.(uint), user); err != nil {
+	if err := h.userService.UpdateUserInfo(userID, user); err != nil {
 		config.Logger.Error(err)
 		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
 		return
@@ -186,13 +204,13 @@ func (h *UserHandler) DeleteFriend(c *gin.Context) {
 	}
 
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 
-	if err := h.userService.DeleteFriend(userID.(uint), deleteFriendDTO.FriendID); err != nil {
+	if err := h.userService.DeleteFriend(userID, deleteFriendDTO.FriendID); err != nil {
 		config.Logger.Error(err)
 		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
 		return
@@ -201,11 +219,59 @@ func (h *UserHandler) DeleteFriend(c *gin.Context) {
 	model.SendResponse(c, http.StatusOK, model.Success("删除好友成功", nil))
 }
 
+// BlockUser 拉黑用户
+func (h *UserHandler) BlockUser(c *gin.Context) {
+	var blockDTO dto.BlockUserDTO
+	if err := c.ShouldBindJSON(&blockDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.userService.BlockUser(userID, blockDTO.TargetID); err != nil {
+		config.Logger.Error(err)
+		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("拉黑成功", nil))
+}
+
+// UnblockUser 取消拉黑用户
+func (h *UserHandler) UnblockUser(c *gin.Context) {
+	var blockDTO dto.BlockUserDTO
+	if err := c.ShouldBindJSON(&blockDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.userService.UnblockUser(userID, blockDTO.TargetID); err != nil {
+		config.Logger.Error(err)
+		model.SendResponse(c, http.StatusInternalServerError, model.Error(err.Error()))
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("取消拉黑成功", nil))
+}
+
 // UploadAvatar 上传用户头像
 func (h *UserHandler) UploadAvatar(c *gin.Context) {
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
@@ -260,14 +326,14 @@ func isImageFile(filename string) bool {
 // CheckToken 检查 token 是否存在
 func (h *UserHandler) CheckToken(c *gin.Context) {
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
 		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
 		return
 	}
 
 	// 检查 token 是否存在
-	exists, err := h.userService.CheckToken(userID.(uint))
+	exists, err := h.userService.CheckToken(userID, middle.CurrentSessionID(c))
 	if err != nil {
 		config.Logger.Error(err)
 		model.SendResponse(c, http.StatusInternalServerError, model.Error("检查token失败"))
@@ -281,3 +347,82 @@ func (h *UserHandler) CheckToken(c *gin.Context) {
 
 	model.SendResponse(c, http.StatusOK, model.Success("token有效", nil))
 }
+
+// ChangePhone 修改手机号
+func (h *UserHandler) ChangePhone(c *gin.Context) {
+	var changeDTO dto.ChangePhoneDTO
+	if err := c.ShouldBindJSON(&changeDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.userService.ChangePhone(userID, changeDTO.PhoneNumber, changeDTO.Code); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("修改手机号成功", nil))
+}
+
+// ChangeEmail 修改邮箱
+func (h *UserHandler) ChangeEmail(c *gin.Context) {
+	var changeDTO dto.ChangeEmailDTO
+	if err := c.ShouldBindJSON(&changeDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.userService.ChangeEmail(userID, changeDTO.Email, changeDTO.Code); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("修改邮箱成功", nil))
+}
+
+// ChangePassword 修改密码
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	var changeDTO dto.ChangePasswordDTO
+	if err := c.ShouldBindJSON(&changeDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+
+	if err := h.userService.ChangePassword(userID, changeDTO.OldPassword, changeDTO.NewPassword); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("修改密码成功，请重新登录", nil))
+}
+
+// ResetPassword 通过验证码重置密码
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var resetDTO dto.ResetPasswordDTO
+	if err := c.ShouldBindJSON(&resetDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+
+	if err := h.userService.ResetPassword(resetDTO.Target, resetDTO.Code, resetDTO.NewPassword); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("重置密码成功，请重新登录", nil))
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"im-system/internal/middle"
+	"im-system/internal/model"
+	"im-system/internal/model/dto"
+	"im-system/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VerificationHandler struct {
+	verificationService *service.VerificationService
+}
+
+func NewVerificationHandler(verificationService *service.VerificationService) *VerificationHandler {
+	return &VerificationHandler{verificationService: verificationService}
+}
+
+// SendCode 未登录时发送验证码，用于注册和重置密码
+func (h *VerificationHandler) SendCode(c *gin.Context) {
+	var sendCodeDTO dto.SendCodeDTO
+	if err := c.ShouldBindJSON(&sendCodeDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+	if sendCodeDTO.Purpose != service.VerifyRegister && sendCodeDTO.Purpose != service.VerifyResetPassword {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("不支持的验证码用途"))
+		return
+	}
+
+	if err := h.verificationService.SendCode(sendCodeDTO.Purpose, sendCodeDTO.Target, 0, c.ClientIP()); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("验证码已发送", nil))
+}
+
+// SendUserCode 登录后发送验证码，用于修改手机号和邮箱
+func (h *VerificationHandler) SendUserCode(c *gin.Context) {
+	var sendCodeDTO dto.SendCodeDTO
+	if err := c.ShouldBindJSON(&sendCodeDTO); err != nil {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("无效的请求"))
+		return
+	}
+	userID, ok := middle.CurrentUserID(c)
+	if !ok {
+		model.SendResponse(c, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+	if sendCodeDTO.Purpose != service.VerifyChangePhone && sendCodeDTO.Purpose != service.VerifyChangeEmail {
+		model.SendResponse(c, http.StatusBadRequest, model.Error("不支持的验证码用途"))
+		return
+	}
+
+	if err := h.verificationService.SendCode(sendCodeDTO.Purpose, sendCodeDTO.Target, userID, c.ClientIP()); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	model.SendResponse(c, http.StatusOK, model.Success("验证码已发送", nil))
+}
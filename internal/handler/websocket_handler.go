@@ -5,11 +5,12 @@ import (
 	"im-system/internal/config"
 	"log"
 	"net/http"
-	"strconv"
-	"sync"
 
 	"github.com/gin-gonic/gin"
 
+	"im-system/internal/middle"
+	"im-system/internal/model"
+	"im-system/internal/module/hub"
 	"im-system/internal/service"
 
 	"github.com/gorilla/websocket"
@@ -21,14 +22,9 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-// 存储所有连接的客户端
-var clients = make(map[string]*websocket.Conn)
-
 type WebSocketHandler struct {
-	clients        map[string]*websocket.Conn
-	groupClients   map[int][]*websocket.Conn
+	hub            *hub.Hub // 存储所有连接的客户端
 	broadcast      chan Message
-	mu             sync.Mutex
 	messageService *service.MessageService
 }
 
@@ -40,25 +36,33 @@ type Message struct {
 	SenderName  string `json:"senderName"`
 	Avatar      string `json:"avatar"`
 	Content     string `json:"content"`
-	MessageType string `json:"messageType"` // "private" 或 "group"
-	CreatedAt   string `json:"createdAt"`   // 2025-05-07T16:17:21+08:00
+	MessageType string `json:"messageType"`        // "private" 或 "group"
+	CreatedAt   string `json:"createdAt"`          // 2025-05-07T16:17:21+08:00
+	Nickname    string `json:"nickname,omitempty"` // 发送者的群昵称，用于群聊
+	Title       string `json:"title,omitempty"`    // 发送者的群称号，用于群聊
+	Level       int    `json:"level,omitempty"`    // 发送者的群等级，用于群聊
 }
 
 func NewWebSocketHandler(messageService *service.MessageService) *WebSocketHandler {
 	return &WebSocketHandler{
-		clients:        clients,
+		hub:            hub.Default,
 		broadcast:      make(chan Message),
 		messageService: messageService,
 	}
 }
 
-// SendMessage 发送消息
+// SendMessage 发送消息，连接由 AuthWSMiddleware 鉴权，用户ID取自 token
 func (h *WebSocketHandler) SendMessage(ctx *gin.Context) {
-	h.handleWebSocket(ctx.Writer, ctx.Request)
+	userID, ok := middle.CurrentUserID(ctx)
+	if !ok {
+		model.SendResponse(ctx, http.StatusUnauthorized, model.Error("用户未登录"))
+		return
+	}
+	h.handleWebSocket(ctx.Writer, ctx.Request, userID, middle.CurrentSessionID(ctx))
 }
 
 // handleWebSocket 处理WebSocket连接
-func (h *WebSocketHandler) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+func (h *WebSocketHandler) handleWebSocket(w http.ResponseWriter, r *http.Request, userID uint, sessionID string) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println("Upgrade error:", err)
@@ -66,30 +70,40 @@ func (h *WebSocketHandler) handleWebSocket(w http.ResponseWriter, r *http.Reques
 	}
 	defer conn.Close()
 
-	// 读取客户端发送的用户名
-	_, username, err := conn.ReadMessage()
-	if err != nil {
-		log.Println("Read username error:", err)
-		return
-	}
-	// 判断是否重复
-	clients[string(username)] = conn
+	// 同一个用户可以有多个设备同时在线
+	client := h.hub.Register(userID, sessionID, conn)
+	defer h.hub.Unregister(userID, client)
 
 	for {
 		// 读取客户端发送的消息
 		_, message, err := conn.ReadMessage()
 		if err != nil {
 			log.Println("Read message error:", err)
-			delete(clients, string(username))
 			break
 		}
-		// 解析消息
+		// 解析消息，旧版客户端连接后先发送的用户ID无法解析，直接忽略
 		var msg Message
 		err = json.Unmarshal(message, &msg)
 		if err != nil {
 			log.Println("Unmarshal message error:", err)
 			continue
 		}
+		// 发送者必须是当前连接的用户，防止冒充他人发送消息
+		if msg.SenderId != int(userID) {
+			log.Println("Sender mismatch:", msg.SenderId, userID)
+			continue
+		}
+		// 群聊消息附带发送者的群昵称、称号和等级
+		if msg.MessageType == "group" {
+			if member, err := h.messageService.GetGroupMember(uint(msg.GroupID), uint(msg.SenderId)); err == nil {
+				msg.Nickname = member.Nickname
+				msg.Title = member.Title
+				msg.Level = member.Level
+				if enriched, err := json.Marshal(msg); err == nil {
+					message = enriched
+				}
+			}
+		}
 		// 保存消息到数据库
 		// 判断是群消息还是私聊消息
 		if msg.MessageType == "group" {
@@ -112,15 +126,7 @@ func (h *WebSocketHandler) handleWebSocket(w http.ResponseWriter, r *http.Reques
 		log.Println(msg)
 		if msg.MessageType == "private" {
 			// 处理私聊消息
-			receiver := strconv.FormatInt(int64(msg.ReceiverID), 10)
-			if client, OK := clients[receiver]; OK {
-				err := client.WriteMessage(websocket.TextMessage, message)
-				if err != nil {
-					log.Println("Write message error:", err)
-					client.Close()
-					delete(clients, receiver)
-				}
-			}
+			h.hub.SendToUser(uint(msg.ReceiverID), message)
 		} else if msg.MessageType == "group" {
 			// 处理群聊消息
 			// 使用 messageService 获取群组成员
@@ -131,14 +137,8 @@ func (h *WebSocketHandler) handleWebSocket(w http.ResponseWriter, r *http.Reques
 			}
 			// 发送消息给群组中的所有成员
 			for _, member := range members {
-				receiver := strconv.FormatInt(int64(member.UserID), 10)
-
-				if client, OK := clients[receiver]; OK && int(member.UserID) != msg.SenderId {
-					err := client.WriteMessage(websocket.TextMessage, message)
-					if err != nil {
-						log.Println("Write message error:", err)
-						client.Close()
-					}
+				if int(member.UserID) != msg.SenderId {
+					h.hub.SendToUser(member.UserID, message)
 				}
 			}
 		}
@@ -0,0 +1,38 @@
+package middle
+
+import (
+	"im-system/internal/model"
+	"im-system/internal/model/db"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"im-system/internal/config"
+)
+
+// AdminMiddleware 管理员鉴权中间件，需要挂载在 AuthMiddleware 之后
+// 角色不随用户信息缓存，每次从数据库读取，撤销管理员后立即生效
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := CurrentUserID(c)
+		if !ok {
+			model.SendResponse(c, http.StatusUnauthorized, model.Unauthorized("用户未登录"))
+			c.Abort()
+			return
+		}
+
+		var user db.User
+		if err := db.DB.Select("id", "role").First(&user, userID).Error; err != nil {
+			config.Logger.Error(err)
+			model.SendResponse(c, http.StatusUnauthorized, model.Unauthorized("请重新登录"))
+			c.Abort()
+			return
+		}
+		if user.Role != db.UserRoleAdmin {
+			model.SendResponse(c, http.StatusForbidden, model.Error("没有管理员权限"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -5,11 +5,14 @@ import (
 	"net/http"
 )
 
-// AuthWSMiddleware WebSocket 鉴权中间件
+// AuthWSMiddleware WebSocket 鉴权中间件，在升级连接之前校验 token
 func AuthWSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 从请求头中获取 Token
-		token := c.Query("token")
+		// 浏览器建立 WebSocket 连接时无法设置请求头，优先使用请求头，其次使用 URL 参数中的 token
+		token := c.Request.Header.Get("token")
+		if token == "" {
+			token = c.Query("token")
+		}
 		if token == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "未提供token"})
 			c.Abort()
@@ -17,15 +20,15 @@ func AuthWSMiddleware() gin.HandlerFunc {
 		}
 
 		// 验证 Token
-		userID, err := ValidateJWT(token)
+		claims, err := ValidateJWT(token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的token"})
 			c.Abort()
 			return
 		}
 
-		// 将用户ID存储到上下文中
-		c.Set("user_id", userID)
+		// 将用户ID和会话ID存储到上下文中
+		setCurrentUser(c, claims, nil)
 
 		// 继续处理请求
 		c.Next()
@@ -0,0 +1,48 @@
+package middle
+
+import (
+	"im-system/internal/module/usercache"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 鉴权中间件保存当前登录用户时使用的上下文 key
+const (
+	contextUserIDKey    = "user_id"
+	contextSessionIDKey = "session_id"
+	contextUserInfoKey  = "user_info"
+)
+
+// setCurrentUser 鉴权通过后将用户ID、会话ID和用户信息存储到上下文中，userInfo 可以为 nil
+func setCurrentUser(c *gin.Context, claims *Claims, userInfo *usercache.CachedUser) {
+	c.Set(contextUserIDKey, claims.UserID)
+	c.Set(contextSessionIDKey, claims.SessionID)
+	if userInfo != nil {
+		c.Set(contextUserInfoKey, userInfo)
+	}
+}
+
+// CurrentUserID 获取当前登录用户的ID，请求未经过鉴权中间件时返回 false
+func CurrentUserID(c *gin.Context) (uint, bool) {
+	value, exists := c.Get(contextUserIDKey)
+	if !exists {
+		return 0, false
+	}
+	userID, ok := value.(uint)
+	return userID, ok && userID != 0
+}
+
+// CurrentSessionID 获取当前登录会话的ID，未登录时返回空字符串
+func CurrentSessionID(c *gin.Context) string {
+	return c.GetString(contextSessionIDKey)
+}
+
+// CurrentUserInfo 获取当前登录用户的信息，不包含密码等凭据。WebSocket 鉴权不加载用户信息，此时返回 false
+func CurrentUserInfo(c *gin.Context) (*usercache.CachedUser, bool) {
+	value, exists := c.Get(contextUserInfoKey)
+	if !exists {
+		return nil, false
+	}
+	userInfo, ok := value.(*usercache.CachedUser)
+	return userInfo, ok && userInfo != nil
+}
@@ -1,11 +1,7 @@
 package middle
 
 import (
-	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
-	"im-system/internal/model/db"
 	"time"
 
 	"im-system/internal/config"
@@ -15,25 +11,20 @@ import (
 
 // Claims 自定义的 JWT 载荷
 type Claims struct {
-	UserID uint `json:"user_id"`
+	UserID    uint   `json:"user_id"`
+	SessionID string `json:"sid"` // 所属的登录会话
 	jwt.StandardClaims
 }
 
-func GetRedisUserInfoKey(userId uint) string {
-	return fmt.Sprintf("user_login_id:%d", userId)
-}
-
-// GetRedisJWTKey 获取 JWT 的 Redis key
-func GetRedisJWTKey(userId uint) string {
-	return fmt.Sprintf("login:user:jwt:%d", userId)
-}
-
-// GenerateJWT 生成 JWT
-func GenerateJWT(userID uint) (string, error) {
+// GenerateJWT 生成短期有效的访问令牌
+func GenerateJWT(userID uint, sessionID string) (string, error) {
+	now := time.Now()
 	claims := Claims{
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 72).Unix(), // 72小时后过期
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(time.Duration(config.Auth.AccessTokenTTL) * time.Second).Unix(),
 		},
 	}
 
@@ -41,62 +32,26 @@ func GenerateJWT(userID uint) (string, error) {
 	return token.SignedString([]byte(config.JWTSecret))
 }
 
-// ValidateJWT 验证 JWT
-func ValidateJWT(tokenString string) (uint, error) {
+// ValidateJWT 验证 JWT，并检查所属会话是否仍然有效
+func ValidateJWT(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("无效的签名算法")
+		}
 		return []byte(config.JWTSecret), nil
 	})
 
-	if err != nil || !token.Valid {
-		return 0, errors.New("无效的token")
+	if err != nil || !token.Valid || claims.SessionID == "" {
+		return nil, errors.New("无效的token")
 	}
 
-	// 从 Redis 中获取存储的 JWT
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	storedToken, err := config.RedisClient.Get(ctx, GetRedisJWTKey(claims.UserID)).Result()
+	// 会话被注销或过期后，未到期的访问令牌也随之失效
+	session, err := GetSession(claims.UserID, claims.SessionID)
 	if err != nil {
-		return 0, errors.New("token已过期或不存在")
+		return nil, errors.New("token已过期或不存在")
 	}
+	touchSession(session)
 
-	// 比对当前 token 和存储的 token 是否一致
-	if storedToken != tokenString {
-		return 0, errors.New("token已失效")
-	}
-
-	return claims.UserID, nil
-}
-
-// SetTokenToRedis 将 token 和用户信息存储到 Redis
-func SetTokenToRedis(userID uint, user db.User) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	redisKey := GetRedisUserInfoKey(userID)
-	jwtKey := GetRedisJWTKey(userID)
-
-	marshal, err := json.Marshal(user)
-	if err != nil {
-		return err
-	}
-
-	// 生成 JWT
-	token, err := GenerateJWT(userID)
-	if err != nil {
-		return err
-	}
-
-	// 使用 pipeline 批量执行 Redis 命令
-	pipe := config.RedisClient.Pipeline()
-
-	// 存储用户基本信息
-	pipe.Set(ctx, redisKey, marshal, 72*time.Hour)
-	// 存储 JWT token
-	pipe.Set(ctx, jwtKey, token, 72*time.Hour)
-
-	// 执行 pipeline
-	_, err = pipe.Exec(ctx)
-	return err
+	return claims, nil
 }
@@ -0,0 +1,315 @@
+package middle
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"im-system/internal/config"
+	"im-system/internal/model/db"
+	"im-system/internal/module/hub"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Session 登录会话，每个设备登录一次生成一条
+type Session struct {
+	ID           string    `json:"id"`           // 会话ID
+	UserID       uint      `json:"user_id"`      // 用户ID
+	DeviceName   string    `json:"device_name"`  // 设备名称
+	IP           string    `json:"ip"`           // 最近一次使用的IP
+	RefreshHash  string    `json:"refresh_hash"` // 当前刷新令牌的哈希，刷新后轮换
+	CreatedAt    time.Time `json:"created_at"`   // 登录时间
+	LastActiveAt time.Time `json:"-"`            // 最后活跃时间，单独记录在会话集合的分数中
+}
+
+// TokenPair 登录或刷新后下发的令牌
+type TokenPair struct {
+	UserID       uint   // 用户ID
+	SessionID    string // 会话ID
+	AccessToken  string // 访问令牌
+	RefreshToken string // 刷新令牌
+	ExpiresIn    int    // 访问令牌有效期（秒）
+}
+
+// GetRedisSessionKey 获取登录会话的 Redis key
+func GetRedisSessionKey(userId uint, sessionID string) string {
+	return fmt.Sprintf("login:user:session:%d:%s", userId, sessionID)
+}
+
+// GetRedisSessionSetKey 获取用户会话集合的 Redis key，有序集合的分数为会话最后活跃时间
+func GetRedisSessionSetKey(userId uint) string {
+	return fmt.Sprintf("login:user:sessions:%d", userId)
+}
+
+// refreshTTL 刷新令牌及会话的有效期
+func refreshTTL() time.Duration {
+	return time.Duration(config.Auth.RefreshTokenTTL) * time.Second
+}
+
+// CreateSession 为用户在新设备上创建会话
+func CreateSession(user db.User, deviceName, ip string) (TokenPair, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sessionID, err := randomToken(16)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	now := time.Now()
+	session := Session{
+		ID:           sessionID,
+		UserID:       user.ID,
+		DeviceName:   deviceName,
+		IP:           ip,
+		RefreshHash:  hashRefreshSecret(secret),
+		CreatedAt:    now,
+		LastActiveAt: now,
+	}
+	sessionData, err := json.Marshal(session)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	ttl := refreshTTL()
+	pipe := config.RedisClient.TxPipeline()
+	pipe.Set(ctx, GetRedisSessionKey(user.ID, sessionID), sessionData, ttl)
+	pipe.ZAdd(ctx, GetRedisSessionSetKey(user.ID), &redis.Z{Score: float64(now.Unix()), Member: sessionID})
+	pipe.Expire(ctx, GetRedisSessionSetKey(user.ID), ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return TokenPair{}, err
+	}
+
+	return issueTokens(session, secret)
+}
+
+// RefreshSession 使用刷新令牌换取新的令牌，旧的刷新令牌随即失效。
+// 已轮换掉的刷新令牌被再次使用时视为泄露，直接注销整个会话
+func RefreshSession(refreshToken, ip string) (TokenPair, error) {
+	userID, sessionID, secret, err := parseRefreshToken(refreshToken)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	key := GetRedisSessionKey(userID, sessionID)
+	var session Session
+	var newSecret string
+	reused := false
+	// 使用 WATCH 保证同一个刷新令牌只能成功轮换一次
+	err = config.RedisClient.Watch(ctx, func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Result()
+		if err != nil {
+			return errors.New("登录已过期，请重新登录")
+		}
+		if err := json.Unmarshal([]byte(data), &session); err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare([]byte(hashRefreshSecret(secret)), []byte(session.RefreshHash)) != 1 {
+			reused = true
+			return errors.New("刷新令牌已失效，请重新登录")
+		}
+
+		newSecret, err = randomToken(32)
+		if err != nil {
+			return err
+		}
+		session.RefreshHash = hashRefreshSecret(newSecret)
+		session.IP = ip
+		updated, err := json.Marshal(session)
+		if err != nil {
+			return err
+		}
+
+		ttl := refreshTTL()
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, updated, ttl)
+			pipe.ZAdd(ctx, GetRedisSessionSetKey(userID), &redis.Z{Score: float64(time.Now().Unix()), Member: sessionID})
+			pipe.Expire(ctx, GetRedisSessionSetKey(userID), ttl)
+			return nil
+		})
+		return err
+	}, key)
+	if errors.Is(err, redis.TxFailedErr) {
+		return TokenPair{}, errors.New("刷新令牌已失效，请重新登录")
+	}
+	if err != nil {
+		if reused {
+			if revokeErr := RevokeSession(userID, sessionID); revokeErr != nil {
+				config.Logger.Error(revokeErr)
+			}
+		}
+		return TokenPair{}, err
+	}
+
+	return issueTokens(session, newSecret)
+}
+
+// GetSession 获取用户的某个会话
+func GetSession(userID uint, sessionID string) (Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data, err := config.RedisClient.Get(ctx, GetRedisSessionKey(userID, sessionID)).Result()
+	if err != nil {
+		return Session{}, err
+	}
+	var session Session
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		return Session{}, err
+	}
+	return session, nil
+}
+
+// ListSessions 获取用户所有有效的会话，按最后活跃时间倒序，并清理已过期的会话ID
+func ListSessions(userID uint) ([]Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	setKey := GetRedisSessionSetKey(userID)
+	members, err := config.RedisClient.ZRevRangeWithScores(ctx, setKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]Session, 0, len(members))
+	if len(members) == 0 {
+		return sessions, nil
+	}
+
+	ids := make([]string, len(members))
+	keys := make([]string, len(members))
+	for i, member := range members {
+		ids[i], _ = member.Member.(string)
+		keys[i] = GetRedisSessionKey(userID, ids[i])
+	}
+	values, err := config.RedisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	stale := make([]interface{}, 0)
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			stale = append(stale, ids[i])
+			continue
+		}
+		var session Session
+		if err := json.Unmarshal([]byte(data), &session); err != nil {
+			config.Logger.Error(err)
+			continue
+		}
+		session.LastActiveAt = time.Unix(int64(members[i].Score), 0)
+		sessions = append(sessions, session)
+	}
+	if len(stale) > 0 {
+		if err := config.RedisClient.ZRem(ctx, setKey, stale...).Err(); err != nil {
+			config.Logger.Error(err)
+		}
+	}
+	return sessions, nil
+}
+
+// RevokeSession 注销用户的某个会话，该会话的访问令牌和刷新令牌立即失效，已建立的 WebSocket 连接同时关闭
+func RevokeSession(userID uint, sessionID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pipe := config.RedisClient.TxPipeline()
+	pipe.Del(ctx, GetRedisSessionKey(userID, sessionID))
+	pipe.ZRem(ctx, GetRedisSessionSetKey(userID), sessionID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	hub.Default.CloseSession(userID, sessionID)
+	return nil
+}
+
+// RevokeAllSessions 注销用户的所有会话，并关闭用户所有的 WebSocket 连接
+func RevokeAllSessions(userID uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	setKey := GetRedisSessionSetKey(userID)
+	ids, err := config.RedisClient.ZRange(ctx, setKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	keys := []string{setKey}
+	for _, id := range ids {
+		keys = append(keys, GetRedisSessionKey(userID, id))
+	}
+	if err := config.RedisClient.Del(ctx, keys...).Err(); err != nil {
+		return err
+	}
+	hub.Default.CloseUser(userID)
+	return nil
+}
+
+// touchSession 更新会话的最后活跃时间，会话已被注销时不做任何修改
+func touchSession(session Session) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	member := &redis.Z{Score: float64(time.Now().Unix()), Member: session.ID}
+	if err := config.RedisClient.ZAddXX(ctx, GetRedisSessionSetKey(session.UserID), member).Err(); err != nil {
+		config.Logger.Error(err)
+	}
+}
+
+// issueTokens 为会话签发访问令牌，并拼接刷新令牌
+func issueTokens(session Session, secret string) (TokenPair, error) {
+	accessToken, err := GenerateJWT(session.UserID, session.ID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return TokenPair{
+		UserID:       session.UserID,
+		SessionID:    session.ID,
+		AccessToken:  accessToken,
+		RefreshToken: fmt.Sprintf("%d.%s.%s", session.UserID, session.ID, secret),
+		ExpiresIn:    config.Auth.AccessTokenTTL,
+	}, nil
+}
+
+// parseRefreshToken 解析刷新令牌，格式为 "<用户ID>.<会话ID>.<随机串>"
+func parseRefreshToken(refreshToken string) (uint, string, string, error) {
+	parts := strings.Split(refreshToken, ".")
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return 0, "", "", errors.New("无效的刷新令牌")
+	}
+	userID, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, "", "", errors.New("无效的刷新令牌")
+	}
+	return uint(userID), parts[1], parts[2], nil
+}
+
+// hashRefreshSecret 刷新令牌只保存哈希，Redis 泄露时无法直接使用
+func hashRefreshSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken 生成 URL 安全的随机字符串
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
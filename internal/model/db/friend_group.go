@@ -8,7 +8,8 @@ import (
 type FriendGroup struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`             // 主键
 	UserID    uint      `gorm:"not null" json:"user_id"`          // 用户ID，不能为空
-	GroupName string    `gorm:"not null" json:"group_name"`       // 分组名称，不能为空
+	GroupName string    `gorm:"not null" json:"group_name"`       // 分组名称，不能为空，同一个用户下不能重复
+	SortOrder int       `gorm:"default:0" json:"sort_order"`      // 排序，越小越靠前
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"` // 创建时间
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"` // 更新时间
 }
@@ -6,14 +6,18 @@ import (
 
 // Friendship 好友关系表结构体
 type Friendship struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`             // 好友关系ID，自增主键
-	UserID    uint      `gorm:"not null" json:"user_id"`          // 用户ID，不能为空
-	FriendID  uint      `gorm:"not null" json:"friend_id"`        // 好友的用户ID，不能为空
-	Status    string    `gorm:"default:'pending'" json:"status"`  // 好友关系状态，默认为 'pending', 通过为 'accepted'
-	GroupID   uint      `gorm:"default:NULL" json:"group_id"`     // 分组ID，允许为空， 0 为默认的好友分组
-	Remark    string    `gorm:"default:''" json:"remark"`         // 好友备注，允许为空
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"` // 创建时间
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"` // 更新时间
+	ID           uint      `gorm:"primaryKey" json:"id"`               // 好友关系ID，自增主键
+	UserID       uint      `gorm:"not null" json:"user_id"`            // 用户ID，不能为空
+	FriendID     uint      `gorm:"not null" json:"friend_id"`          // 好友的用户ID，不能为空
+	Status       string    `gorm:"default:'pending'" json:"status"`    // 好友关系状态：pending、accepted、rejected、deleted、blocked，默认为 'pending'
+	GroupID      uint      `gorm:"default:NULL" json:"group_id"`       // 分组ID，允许为空， 0 为默认的好友分组
+	Remark       string    `gorm:"default:''" json:"remark"`           // 好友备注，允许为空
+	Starred      bool      `gorm:"default:false" json:"starred"`       // 是否特别关心
+	Muted        bool      `gorm:"default:false" json:"muted"`         // 是否消息免打扰
+	Pinned       bool      `gorm:"default:false" json:"pinned"`        // 是否置顶会话
+	HidePresence bool      `gorm:"default:false" json:"hide_presence"` // 是否对该好友隐藏在线状态
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`   // 创建时间
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`   // 更新时间
 	//DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`          // 删除时间
 }
 
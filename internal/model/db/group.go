@@ -12,6 +12,9 @@ type Group struct {
 	GroupAvatar  string    `gorm:"default:'https://encrypted-tbn0.gstatic.com/images?q=tbn:ANd9GcQ3w2fqb71MsCj97IKLAUXoI6BS4IfeCeEoq_XGS3X2CErGlYyP4xxX4eQ&s'" json:"group_avatar"` // 群组头像，不能为空
 	Announcement string    `gorm:"default:''" json:"announcement"`                                                                                                                  // 群公告，允许为空
 	Description  string    `gorm:"default:''" json:"description"`                                                                                                                   // 群描述，允许为空
+	Tier         string    `gorm:"default:'normal'" json:"tier"`                                                                                                                    // 群等级，决定默认的成员上限
+	MaxMembers   int       `gorm:"default:0" json:"max_members"`                                                                                                                    // 单群成员上限覆盖值，0 表示使用群等级的上限
+	Category     string    `gorm:"default:''" json:"category"`                                                                                                                      // 群分类，允许为空
 	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`                                                                                                                // 创建时间
 	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`                                                                                                                // 更新时间
 	//DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`          // 删除时间
@@ -0,0 +1,26 @@
+package db
+
+import (
+	"time"
+)
+
+// GroupAnnouncement 群公告表结构体，每次发布都是一条新的公告，历史公告保留
+type GroupAnnouncement struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`             // 主键
+	GroupID    uint      `gorm:"not null" json:"group_id"`         // 群组ID，不能为空
+	AuthorID   uint      `gorm:"not null" json:"author_id"`        // 发布者的用户ID，不能为空
+	Version    int       `gorm:"not null" json:"version"`          // 公告版本号，同一个群内递增
+	Content    string    `gorm:"type:text" json:"content"`         // 公告内容
+	Pinned     bool      `gorm:"default:false" json:"pinned"`      // 是否置顶
+	RequireAck bool      `gorm:"default:false" json:"require_ack"` // 是否需要成员确认已读
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"` // 创建时间
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"` // 更新时间
+}
+
+// GroupAnnouncementAck 群公告确认记录表结构体
+type GroupAnnouncementAck struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`             // 主键
+	AnnouncementID uint      `gorm:"not null" json:"announcement_id"`  // 公告ID，不能为空
+	UserID         uint      `gorm:"not null" json:"user_id"`          // 确认的用户ID，不能为空
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"` // 确认时间
+}
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"time"
+)
+
+// GroupInviteLink 群邀请链接表结构体
+type GroupInviteLink struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`             // 主键
+	GroupID   uint       `gorm:"not null" json:"group_id"`         // 群组ID，不能为空
+	CreatorID uint       `gorm:"not null" json:"creator_id"`       // 创建者的用户ID，不能为空
+	Code      string     `gorm:"unique;not null" json:"code"`      // 邀请码，唯一，不能为空
+	ExpiresAt *time.Time `gorm:"default:NULL" json:"expires_at"`   // 过期时间，为空表示永不过期
+	MaxUses   int        `gorm:"default:0" json:"max_uses"`        // 最大使用次数，0 表示不限制
+	UsedCount int        `gorm:"default:0" json:"used_count"`      // 已使用次数
+	Revoked   bool       `gorm:"default:false" json:"revoked"`     // 是否已撤销
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"` // 创建时间
+	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"` // 更新时间
+}
+
+// GroupInviteLinkUsage 群邀请链接使用记录表结构体，用于审计谁通过哪个链接入群
+type GroupInviteLinkUsage struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`             // 主键
+	LinkID    uint      `gorm:"not null" json:"link_id"`          // 邀请链接ID，不能为空
+	GroupID   uint      `gorm:"not null" json:"group_id"`         // 群组ID，不能为空
+	UserID    uint      `gorm:"not null" json:"user_id"`          // 入群用户ID，不能为空
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"` // 入群时间
+}
@@ -0,0 +1,18 @@
+package db
+
+import (
+	"time"
+)
+
+// SystemLog 系统日志表结构体，群组审计日志也记录在这张表中
+type SystemLog struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`             // 主键
+	LogType     string    `gorm:"default:''" json:"log_type"`       // 日志类型
+	Message     string    `gorm:"type:text" json:"message"`         // 日志内容
+	GroupID     uint      `gorm:"default:0" json:"group_id"`        // 群组ID，非群组日志为0
+	ActorID     uint      `gorm:"default:0" json:"actor_id"`        // 操作者ID
+	TargetID    uint      `gorm:"default:0" json:"target_id"`       // 被操作的用户ID，没有时为0
+	BeforeValue string    `gorm:"type:text" json:"before_value"`    // 操作前的值(JSON)
+	AfterValue  string    `gorm:"type:text" json:"after_value"`     // 操作后的值(JSON)
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"` // 创建时间
+}
@@ -8,7 +8,8 @@ import (
 type User struct {
 	ID           uint      `gorm:"primaryKey" json:"id"`                // 主键
 	PhoneNumber  string    `gorm:"unique;not null" json:"phone_number"` // 用户电话号码，唯一，不能为空
-	Email        string    `gorm:"unique;not null" json:"email"`        // 用户邮箱，唯一，不能为空
+	Email        *string   `gorm:"unique" json:"email"`                 // 用户邮箱，唯一，未填写时为 NULL
+	Handle       *string   `gorm:"unique" json:"handle"`                // 用户账号名，唯一，可用于登录，未设置时为 NULL
 	Username     string    `gorm:"not null" json:"username"`            // 用户名，不能为空
 	PasswordHash string    `gorm:"not null" json:"password_hash"`       // 密码哈希值，不能为空
 	AvatarURL    string    `gorm:"default:''" json:"avatar_url"`        // 用户头像URL，允许为空
@@ -23,8 +24,21 @@ type User struct {
 	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`    // 记录创建时间，默认为当前时间
 	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`    // 记录更新时间，在更新时自动设置为当前时间
 	//DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at"` // 删除时间
+
+	// 两步验证，不参与 JSON 序列化，避免被注册接口写入或随用户信息缓存
+	TOTPSecret  string `gorm:"column:totp_secret;default:''" json:"-"`     // 两步验证密钥（Base32），未开启时可能是待确认的密钥
+	TOTPEnabled bool   `gorm:"column:totp_enabled;default:false" json:"-"` // 是否已开启两步验证
+
+	// 系统角色，不参与 JSON 序列化，避免被注册接口写入
+	Role string `gorm:"default:'user'" json:"-"` // 系统角色，user 或 admin
 }
 
+// 用户的系统角色
+const (
+	UserRoleUser  = "user"  // 普通用户
+	UserRoleAdmin = "admin" // 管理员
+)
+
 func Register(user User) error {
 	return DB.Create(&user).Error
 }
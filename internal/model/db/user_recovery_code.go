@@ -0,0 +1,12 @@
+package db
+
+import "time"
+
+// UserRecoveryCode 两步验证恢复码，丢失身份验证器时代替验证码使用，每个只能使用一次
+type UserRecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`             // 主键
+	UserID    uint       `gorm:"not null;index" json:"user_id"`    // 用户ID
+	CodeHash  string     `gorm:"not null" json:"-"`                // 恢复码的 SHA-256 哈希
+	UsedAt    *time.Time `gorm:"default:NULL" json:"used_at"`      // 使用时间，未使用时为 NULL
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"` // 创建时间
+}
@@ -0,0 +1,6 @@
+package dto
+
+// RepairFriendshipsDTO 修复好友关系请求参数
+type RepairFriendshipsDTO struct {
+	DryRun bool `json:"dry_run"` // 为 true 时只统计需要修复的记录，不修改数据
+}
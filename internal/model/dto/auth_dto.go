@@ -0,0 +1,76 @@
+package dto
+
+import "im-system/internal/model/db"
+
+// LoginDTO 登录请求参数
+type LoginDTO struct {
+	Account     string `json:"account"`      // 登录账号，可以是手机号、邮箱或账号名
+	PhoneNumber string `json:"phone_number"` // 手机号，兼容旧客户端，account 为空时使用
+	Password    string `json:"password"`     // 密码
+	DeviceName  string `json:"device_name"`  // 设备名称，为空时使用 User-Agent
+}
+
+// RefreshTokenDTO 刷新令牌请求参数
+type RefreshTokenDTO struct {
+	RefreshToken string `json:"refresh_token" binding:"required"` // 刷新令牌
+}
+
+// RevokeSessionDTO 注销会话请求参数
+type RevokeSessionDTO struct {
+	SessionID string `json:"session_id" binding:"required"` // 会话ID
+}
+
+// RegisterDTO 注册请求参数，在用户信息之外携带验证码
+type RegisterDTO struct {
+	db.User
+	Code      string `json:"code" binding:"required"` // 手机验证码
+	EmailCode string `json:"email_code"`              // 邮箱验证码，填写邮箱时必填
+}
+
+// SendCodeDTO 发送验证码请求参数
+type SendCodeDTO struct {
+	Purpose string `json:"purpose" binding:"required"` // 验证码用途
+	Target  string `json:"target" binding:"required"`  // 接收验证码的手机号或邮箱
+}
+
+// ChangePhoneDTO 修改手机号请求参数
+type ChangePhoneDTO struct {
+	PhoneNumber string `json:"phone_number" binding:"required"` // 新手机号
+	Code        string `json:"code" binding:"required"`         // 发送到新手机号的验证码
+}
+
+// ChangeEmailDTO 修改邮箱请求参数
+type ChangeEmailDTO struct {
+	Email string `json:"email" binding:"required"` // 新邮箱
+	Code  string `json:"code" binding:"required"`  // 发送到新邮箱的验证码
+}
+
+// ChangePasswordDTO 修改密码请求参数
+type ChangePasswordDTO struct {
+	OldPassword string `json:"old_password" binding:"required"` // 旧密码
+	NewPassword string `json:"new_password" binding:"required"` // 新密码
+}
+
+// ResetPasswordDTO 重置密码请求参数
+type ResetPasswordDTO struct {
+	Target      string `json:"target" binding:"required"`       // 接收验证码的手机号或邮箱
+	Code        string `json:"code" binding:"required"`         // 验证码
+	NewPassword string `json:"new_password" binding:"required"` // 新密码
+}
+
+// TwoFactorCodeDTO 两步验证码请求参数
+type TwoFactorCodeDTO struct {
+	Code string `json:"code" binding:"required"` // 身份验证器生成的验证码
+}
+
+// DisableTwoFactorDTO 关闭两步验证请求参数
+type DisableTwoFactorDTO struct {
+	Password string `json:"password" binding:"required"` // 登录密码
+	Code     string `json:"code" binding:"required"`     // 验证码或恢复码
+}
+
+// LoginTwoFactorDTO 登录第二步请求参数
+type LoginTwoFactorDTO struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"` // 第一步登录返回的挑战令牌
+	Code           string `json:"code" binding:"required"`            // 验证码或恢复码
+}
@@ -5,4 +5,5 @@ type CreateGroupDTO struct {
 	Name        string `json:"name"`         // 群组名称
 	OwnerID     uint   `json:"owner_id"`     // 群主的用户ID
 	GroupAvatar string `json:"group_avatar"` // 群组头像
+	Category    string `json:"category"`     // 群组分类
 }
@@ -0,0 +1,23 @@
+package dto
+
+// RenameFriendGroupDTO 重命名好友分组
+type RenameFriendGroupDTO struct {
+	GroupID   uint   `json:"group_id" binding:"required"`   // 分组ID
+	GroupName string `json:"group_name" binding:"required"` // 新的分组名称
+}
+
+// ReorderFriendGroupsDTO 重新排列好友分组
+type ReorderFriendGroupsDTO struct {
+	GroupIDs []uint `json:"group_ids" binding:"required"` // 按顺序排列的分组ID
+}
+
+// DeleteFriendGroupDTO 删除好友分组
+type DeleteFriendGroupDTO struct {
+	GroupID uint `json:"group_id" binding:"required"` // 分组ID
+}
+
+// MoveFriendsDTO 批量移动好友到指定分组
+type MoveFriendsDTO struct {
+	GroupID   uint   `json:"group_id" binding:"required"`   // 目标分组ID
+	FriendIDs []uint `json:"friend_ids" binding:"required"` // 好友ID列表
+}
@@ -0,0 +1,20 @@
+package dto
+
+// PublishAnnouncementDTO 发布群公告请求参数
+type PublishAnnouncementDTO struct {
+	GroupID    uint   `json:"group_id" binding:"required"` // 群组ID
+	Content    string `json:"content" binding:"required"`  // 公告内容
+	Pinned     bool   `json:"pinned"`                      // 是否置顶
+	RequireAck bool   `json:"require_ack"`                 // 是否需要成员确认已读
+}
+
+// PinAnnouncementDTO 置顶或取消置顶群公告请求参数
+type PinAnnouncementDTO struct {
+	AnnouncementID uint `json:"announcement_id" binding:"required"` // 公告ID
+	Pinned         bool `json:"pinned"`                             // 是否置顶
+}
+
+// AnnouncementIDDTO 只包含公告ID的请求参数，用于删除和确认公告
+type AnnouncementIDDTO struct {
+	AnnouncementID uint `json:"announcement_id" binding:"required"` // 公告ID
+}
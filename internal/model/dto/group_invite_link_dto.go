@@ -0,0 +1,18 @@
+package dto
+
+// CreateGroupInviteLinkDTO 创建群邀请链接请求参数
+type CreateGroupInviteLinkDTO struct {
+	GroupID   uint  `json:"group_id" binding:"required"` // 群组ID
+	ExpiresIn int64 `json:"expires_in"`                  // 有效期（秒），0 表示永不过期
+	MaxUses   int   `json:"max_uses"`                    // 最大使用次数，0 表示不限制
+}
+
+// RevokeGroupInviteLinkDTO 撤销群邀请链接请求参数
+type RevokeGroupInviteLinkDTO struct {
+	LinkID uint `json:"link_id" binding:"required"` // 邀请链接ID
+}
+
+// JoinGroupByLinkDTO 通过邀请码加入群聊请求参数
+type JoinGroupByLinkDTO struct {
+	Code string `json:"code" binding:"required"` // 邀请码
+}
@@ -0,0 +1,14 @@
+package dto
+
+// SetGroupNicknameDTO 修改群昵称请求参数
+type SetGroupNicknameDTO struct {
+	GroupID  uint   `json:"group_id" binding:"required"` // 群组ID
+	Nickname string `json:"nickname"`                    // 群昵称，为空时恢复为用户名
+}
+
+// SetGroupTitleDTO 授予群称号请求参数
+type SetGroupTitleDTO struct {
+	GroupID  uint   `json:"group_id" binding:"required"`  // 群组ID
+	MemberID uint   `json:"member_id" binding:"required"` // 成员的用户ID
+	Title    string `json:"title"`                        // 群称号，为空时清除称号
+}
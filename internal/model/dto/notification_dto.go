@@ -0,0 +1,15 @@
+package dto
+
+// NotificationIDsDTO 按ID批量操作通知
+type NotificationIDsDTO struct {
+	NotificationIDs []uint `json:"notification_ids" binding:"required"` // 通知ID列表
+}
+
+// QueryNotificationsDTO 查询通知列表
+type QueryNotificationsDTO struct {
+	Type   string `form:"type"`    // 通知类型，为空时不过滤
+	Status string `form:"status"`  // 通知状态，为空时不过滤
+	IsRead *bool  `form:"is_read"` // 是否已读，为空时不过滤
+	Cursor string `form:"cursor"`  // 分页游标，第一页为空
+	Limit  int    `form:"limit"`   // 每页条数，为空时使用配置的默认值
+}
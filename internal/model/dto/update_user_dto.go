@@ -8,4 +8,5 @@ type UpdateUserDTO struct {
 	Birthday  string `json:"birthday"`   // 用户生日，允许为空
 	Bio       string `json:"bio"`        // 用户个人简介，允许为空
 	City      string `json:"city"`       // 用户所在城市，允许为空
+	Handle    string `json:"handle"`     // 用户账号名，允许为空
 }
@@ -5,9 +5,24 @@ type DeleteFriendDTO struct {
 	FriendID uint `json:"friend_id" binding:"required"`
 }
 
+// BlockUserDTO 拉黑或取消拉黑用户请求参数
+type BlockUserDTO struct {
+	TargetID uint `json:"target_id" binding:"required"` // 被拉黑的用户ID
+}
+
 // UpdateFriendGroupDTO 更新好友分组请求参数
 type UpdateFriendGroupDTO struct {
 	FriendID uint   `json:"friend_id" binding:"required"`
 	GroupID  uint   `json:"group_id" binding:"required"`
 	Remark   string `json:"remark"`
 }
+
+// FriendSettingsDTO 好友个性化设置，为空的字段不修改
+type FriendSettingsDTO struct {
+	FriendID     uint    `json:"friend_id" binding:"required"` // 好友ID
+	Remark       *string `json:"remark"`                       // 好友备注
+	Starred      *bool   `json:"starred"`                      // 是否特别关心
+	Muted        *bool   `json:"muted"`                        // 是否消息免打扰
+	Pinned       *bool   `json:"pinned"`                       // 是否置顶会话
+	HidePresence *bool   `json:"hide_presence"`                // 是否对该好友隐藏在线状态
+}
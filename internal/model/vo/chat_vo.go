@@ -9,6 +9,9 @@ type MessageVO struct {
 	SenderID   int       `json:"senderId"`
 	SenderName string    `json:"senderName"`
 	Avatar     string    `json:"avatar"`
+	Nickname   string    `json:"nickname,omitempty"`  // 发送者的群昵称
+	Title      string    `json:"title,omitempty"`     // 发送者的群称号
+	Level      int       `json:"level,omitempty"`     // 发送者的群等级
 	CreatedAt  time.Time `json:"createdAt,omitempty"` // 可以根据实际情况添加时间戳
 }
 
@@ -19,6 +22,8 @@ type ChatVO struct {
 	Avatar      string      `json:"avatar"`
 	LastMessage string      `json:"lastMessage"`
 	Type        string      `json:"type"`
+	Pinned      bool        `json:"pinned"` // 是否置顶会话
+	Muted       bool        `json:"muted"`  // 是否消息免打扰
 	Messages    []MessageVO `json:"messages"`
 }
 
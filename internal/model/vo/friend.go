@@ -20,7 +20,11 @@ type FriendMemberVO struct {
 	Bio         string    `json:"bio"`
 	Gender      string    `json:"gender"`
 	City        string    `json:"city"`
-	Remark      string    `json:"remark"` // 好友备注
+	Remark      string    `json:"remark"`  // 好友备注
+	Status      string    `json:"status"`  // 好友状态（在线/离线）
+	Starred     bool      `json:"starred"` // 是否特别关心
+	Muted       bool      `json:"muted"`   // 是否消息免打扰
+	Pinned      bool      `json:"pinned"`  // 是否置顶会话
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
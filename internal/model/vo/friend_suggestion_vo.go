@@ -0,0 +1,15 @@
+package vo
+
+// FriendSuggestionVO 好友推荐视图对象
+type FriendSuggestionVO struct {
+	ID            uint   `json:"id"`             // 用户ID
+	Username      string `json:"username"`       // 用户名
+	AvatarURL     string `json:"avatar_url"`     // 头像
+	Bio           string `json:"bio"`            // 个人简介
+	Gender        string `json:"gender"`         // 性别
+	City          string `json:"city"`           // 所在城市
+	MutualFriends int    `json:"mutual_friends"` // 共同好友数
+	SharedGroups  int    `json:"shared_groups"`  // 共同群聊数
+	SameCity      bool   `json:"same_city"`      // 是否同城
+	Score         int    `json:"score"`          // 推荐分数，越高越靠前
+}
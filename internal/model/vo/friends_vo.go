@@ -12,6 +12,10 @@ type FriendVO struct {
 	Bio         string    `json:"bio"`          // 好友个人简介
 	Gender      string    `json:"gender"`       // 好友性别
 	City        string    `json:"city"`         // 好友所在城市
+	Remark      string    `json:"remark"`       // 好友备注
+	Starred     bool      `json:"starred"`      // 是否特别关心
+	Muted       bool      `json:"muted"`        // 是否消息免打扰
+	Pinned      bool      `json:"pinned"`       // 是否置顶会话
 	CreatedAt   time.Time `json:"created_at"`   // 创建时间
 	UpdatedAt   time.Time `json:"updated_at"`   // 更新时间
 }
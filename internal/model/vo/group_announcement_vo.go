@@ -0,0 +1,25 @@
+package vo
+
+import "time"
+
+// GroupAnnouncementVO 群公告视图对象
+type GroupAnnouncementVO struct {
+	ID           uint      `json:"id"`            // 公告ID
+	GroupID      uint      `json:"group_id"`      // 群组ID
+	AuthorID     uint      `json:"author_id"`     // 发布者ID
+	AuthorName   string    `json:"author_name"`   // 发布者名称
+	AuthorAvatar string    `json:"author_avatar"` // 发布者头像
+	Version      int       `json:"version"`       // 公告版本号
+	Content      string    `json:"content"`       // 公告内容
+	Pinned       bool      `json:"pinned"`        // 是否置顶
+	RequireAck   bool      `json:"require_ack"`   // 是否需要确认
+	Acked        bool      `json:"acked"`         // 当前用户是否已确认
+	AckCount     int       `json:"ack_count"`     // 已确认人数
+	CreatedAt    time.Time `json:"created_at"`    // 发布时间
+}
+
+// AnnouncementAckVO 群公告确认情况视图对象
+type AnnouncementAckVO struct {
+	Acked   []PreviewMember `json:"acked"`   // 已确认的成员
+	Pending []PreviewMember `json:"pending"` // 未确认的成员
+}
@@ -0,0 +1,28 @@
+package vo
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// GroupAuditVO 群组审计日志视图对象
+type GroupAuditVO struct {
+	ID         uint            `json:"id"`          // 日志ID
+	Action     string          `json:"action"`      // 操作类型
+	ActorID    uint            `json:"actor_id"`    // 操作者ID
+	ActorName  string          `json:"actor_name"`  // 操作者名称
+	TargetID   uint            `json:"target_id"`   // 被操作的用户ID
+	TargetName string          `json:"target_name"` // 被操作的用户名称
+	Before     json.RawMessage `json:"before"`      // 操作前的值
+	After      json.RawMessage `json:"after"`       // 操作后的值
+	Message    string          `json:"message"`     // 日志内容
+	CreatedAt  time.Time       `json:"created_at"`  // 操作时间
+}
+
+// GroupAuditPage 群组审计日志分页结果
+type GroupAuditPage struct {
+	Total    int64          `json:"total"`     // 总条数
+	Page     int            `json:"page"`      // 当前页码
+	PageSize int            `json:"page_size"` // 每页条数
+	Items    []GroupAuditVO `json:"items"`     // 日志列表
+}
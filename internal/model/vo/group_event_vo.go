@@ -0,0 +1,9 @@
+package vo
+
+// GroupMemberChangedVO 群成员变动事件
+type GroupMemberChangedVO struct {
+	GroupID    uint   `json:"group_id"`    // 群组ID
+	Action     string `json:"action"`      // 变动类型：join、remove、quit
+	OperatorID uint   `json:"operator_id"` // 操作者ID
+	UserIDs    []uint `json:"user_ids"`    // 变动的成员ID
+}
@@ -16,9 +16,18 @@ type GroupVO struct {
 
 // GroupStats 群组统计信息
 type GroupStats struct {
-	Active int `json:"active"` // 活跃人数
-	Male   int `json:"male"`   // 男性人数
-	Local  int `json:"local"`  // 本地人数
+	Active       int             `json:"active"`       // 活跃人数
+	Male         int             `json:"male"`         // 男性人数
+	Female       int             `json:"female"`       // 女性人数
+	Other        int             `json:"other"`        // 其他性别人数
+	Local        int             `json:"local"`        // 本地人数
+	MessageTrend []MessageVolume `json:"messageTrend"` // 每日消息量
+}
+
+// MessageVolume 每日消息量
+type MessageVolume struct {
+	Date  string `json:"date"`  // 日期，格式为 2006-01-02
+	Count int    `json:"count"` // 消息数量
 }
 
 // PreviewMember 预览成员信息
@@ -36,6 +45,7 @@ type GroupChatDetail struct {
 	Name           string          `json:"name"`           // 群组名称
 	Avatar         string          `json:"avatar"`         // 群组头像
 	MemberCount    int             `json:"memberCount"`    // 成员数量
+	MaxMembers     int             `json:"maxMembers"`     // 成员上限
 	Category       string          `json:"category"`       // 群组分类
 	Announcement   string          `json:"announcement"`   // 群公告
 	Description    string          `json:"description"`    // 群描述
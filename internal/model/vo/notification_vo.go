@@ -1,19 +1,38 @@
 package vo
 
 import (
-	"im-system/internal/model/db"
 	"time"
 )
 
 // NotificationVO 通知视图对象
 type NotificationVO struct {
-	ID         uint      `json:"id"`         // 通知ID
-	UserID     uint      `json:"user_id"`    // 用户ID
-	ReceiverID uint      `json:"receiver"`   // 接收者ID
-	Type       string    `json:"type"`       // 通知类型
-	Content    string    `json:"content"`    // 通知内容
-	IsRead     bool      `json:"is_read"`    // 是否已读
-	Status     string    `json:"status"`     // 通知状态
-	CreatedAt  time.Time `json:"created_at"` // 创建时间
-	Sender     db.User   `json:"sender"`     // 发送请求的用户信息,或者接收者的信息
+	ID         uint                 `json:"id"`         // 通知ID
+	UserID     uint                 `json:"user_id"`    // 用户ID
+	ReceiverID uint                 `json:"receiver"`   // 接收者ID
+	Type       string               `json:"type"`       // 通知类型
+	Content    string               `json:"content"`    // 通知内容
+	IsRead     bool                 `json:"is_read"`    // 是否已读
+	Status     string               `json:"status"`     // 通知状态
+	GroupID    uint                 `json:"group_id"`   // 群组ID，群组相关的通知使用
+	CreatedAt  time.Time            `json:"created_at"` // 创建时间
+	Sender     NotificationSenderVO `json:"sender"`     // 发送请求的用户信息,或者接收者的信息
+}
+
+// NotificationSenderVO 通知中展示的对方用户信息，只包含公开资料，不包含密码哈希、手机号等字段
+type NotificationSenderVO struct {
+	ID        uint   `json:"id"`         // 用户ID
+	Username  string `json:"username"`   // 用户名
+	AvatarURL string `json:"avatar_url"` // 头像
+	Bio       string `json:"bio"`        // 个性签名
+}
+
+// NotificationUnreadVO 未读通知数
+type NotificationUnreadVO struct {
+	Count int64 `json:"count"` // 未读通知数
+}
+
+// NotificationPageVO 通知分页结果
+type NotificationPageVO struct {
+	Items      []NotificationVO `json:"items"`       // 通知列表
+	NextCursor string           `json:"next_cursor"` // 下一页的游标，没有更多时为空
 }
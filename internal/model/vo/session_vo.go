@@ -0,0 +1,36 @@
+package vo
+
+import "time"
+
+// TokenVO 登录或刷新令牌后返回的令牌信息。
+// 开启了两步验证时，密码验证通过后只返回挑战令牌，令牌字段为空
+type TokenVO struct {
+	UserID            uint   `json:"user_id"`                   // 用户ID
+	Token             string `json:"token"`                     // 访问令牌
+	RefreshToken      string `json:"refresh_token"`             // 刷新令牌，每次刷新后轮换
+	ExpiresIn         int    `json:"expires_in"`                // 访问令牌或挑战令牌的有效期（秒）
+	SessionID         string `json:"session_id"`                // 会话ID
+	TwoFactorRequired bool   `json:"two_factor_required"`       // 是否需要继续进行两步验证
+	ChallengeToken    string `json:"challenge_token,omitempty"` // 两步验证挑战令牌
+}
+
+// TwoFactorEnrollVO 开启两步验证时返回的密钥
+type TwoFactorEnrollVO struct {
+	Secret          string `json:"secret"`           // Base32 编码的密钥，无法扫码时手动输入
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth URI，前端生成二维码供身份验证器扫描
+}
+
+// RecoveryCodesVO 两步验证恢复码，只在生成时返回一次
+type RecoveryCodesVO struct {
+	RecoveryCodes []string `json:"recovery_codes"` // 恢复码
+}
+
+// SessionVO 登录会话
+type SessionVO struct {
+	ID           string    `json:"id"`             // 会话ID
+	DeviceName   string    `json:"device_name"`    // 设备名称
+	IP           string    `json:"ip"`             // 最近一次使用的IP
+	CreatedAt    time.Time `json:"created_at"`     // 登录时间
+	LastActiveAt time.Time `json:"last_active_at"` // 最后活跃时间
+	Current      bool      `json:"current"`        // 是否为当前请求所在的会话
+}
@@ -6,15 +6,20 @@ import (
 
 // UserVO 用户视图对象
 type UserVO struct {
-	ID          uint      `json:"id"`           // 用户ID
-	Username    string    `json:"username"`     // 用户名
-	Email       string    `json:"email"`        // 用户邮箱
-	PhoneNumber string    `json:"phone_number"` // 用户电话号码
-	AvatarURL   string    `json:"avatar_url"`   // 用户头像URL
-	Bio         string    `json:"bio"`          // 用户个人简介
-	Gender      string    `json:"gender"`       // 用户性别
-	CreatedAt   time.Time `json:"created_at"`   // 创建时间
-	UpdatedAt   time.Time `json:"updated_at"`   // 更新时间
-	City        string    `json:"city"`         // 用户城市
-	Role        string    `json:"role"`         // 用户角色
+	ID          uint      `json:"id"`                 // 用户ID
+	Username    string    `json:"username"`           // 用户名
+	Email       string    `json:"email"`              // 用户邮箱
+	Handle      string    `json:"handle"`             // 用户账号名
+	TwoFactor   bool      `json:"two_factor"`         // 是否开启了两步验证
+	PhoneNumber string    `json:"phone_number"`       // 用户电话号码
+	AvatarURL   string    `json:"avatar_url"`         // 用户头像URL
+	Bio         string    `json:"bio"`                // 用户个人简介
+	Gender      string    `json:"gender"`             // 用户性别
+	CreatedAt   time.Time `json:"created_at"`         // 创建时间
+	UpdatedAt   time.Time `json:"updated_at"`         // 更新时间
+	City        string    `json:"city"`               // 用户城市
+	Role        string    `json:"role"`               // 用户角色
+	Nickname    string    `json:"nickname,omitempty"` // 用户在群组中的昵称
+	Title       string    `json:"title,omitempty"`    // 用户在群组中的称号
+	Level       int       `json:"level,omitempty"`    // 用户在群组中的等级
 }
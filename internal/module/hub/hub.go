@@ -0,0 +1,155 @@
+package hub
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// 推送事件类型
+const (
+	EventGroupAnnouncement  = "group_announcement"   // 群公告发布
+	EventGroupMemberChanged = "group_member_changed" // 群成员变动
+	EventNotification       = "notification"         // 新通知
+	EventNotificationUpdate = "notification_updated" // 通知状态变化
+	EventNotificationUnread = "notification_unread"  // 未读通知数变化
+)
+
+// Event 通过 WebSocket 推送给客户端的事件
+type Event struct {
+	Type string      `json:"type"` // 事件类型
+	Data interface{} `json:"data"` // 事件内容
+}
+
+// Client 一个 WebSocket 连接，同一个用户的每个设备对应一个 Client
+type Client struct {
+	conn      *websocket.Conn
+	sessionID string     // 建立连接时使用的登录会话，会话注销时关闭连接
+	mu        sync.Mutex // gorilla/websocket 不支持并发写
+}
+
+// Write 向连接写入一条文本消息
+func (c *Client) Write(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// Hub 管理所有在线用户的 WebSocket 连接
+type Hub struct {
+	mu      sync.RWMutex
+	clients map[uint]map[*Client]struct{}
+}
+
+// Default 全局的连接管理器
+var Default = New()
+
+// New 创建新的连接管理器
+func New() *Hub {
+	return &Hub{
+		clients: make(map[uint]map[*Client]struct{}),
+	}
+}
+
+// Register 注册用户的连接，sessionID 为建立连接时使用的登录会话
+func (h *Hub) Register(userID uint, sessionID string, conn *websocket.Conn) *Client {
+	client := &Client{conn: conn, sessionID: sessionID}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.clients[userID] == nil {
+		h.clients[userID] = make(map[*Client]struct{})
+	}
+	h.clients[userID][client] = struct{}{}
+	return client
+}
+
+// Unregister 注销用户的连接
+func (h *Hub) Unregister(userID uint, client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if conns, ok := h.clients[userID]; ok {
+		delete(conns, client)
+		if len(conns) == 0 {
+			delete(h.clients, userID)
+		}
+	}
+}
+
+// CloseSession 关闭用户某个登录会话的所有连接，会话注销后不再推送消息
+func (h *Hub) CloseSession(userID uint, sessionID string) {
+	h.closeClients(userID, func(client *Client) bool {
+		return client.sessionID == sessionID
+	})
+}
+
+// CloseUser 关闭用户的所有连接，用于注销全部会话
+func (h *Hub) CloseUser(userID uint) {
+	h.closeClients(userID, func(*Client) bool {
+		return true
+	})
+}
+
+// closeClients 关闭并注销用户满足条件的连接，连接的读循环随之退出
+func (h *Hub) closeClients(userID uint, match func(*Client) bool) {
+	h.mu.Lock()
+	var closing []*Client
+	for client := range h.clients[userID] {
+		if match(client) {
+			closing = append(closing, client)
+			delete(h.clients[userID], client)
+		}
+	}
+	if len(h.clients[userID]) == 0 {
+		delete(h.clients, userID)
+	}
+	h.mu.Unlock()
+
+	for _, client := range closing {
+		client.conn.Close()
+	}
+}
+
+// IsOnline 判断用户是否在线
+func (h *Hub) IsOnline(userID uint) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients[userID]) > 0
+}
+
+// SendToUser 向用户的所有在线设备发送原始消息，写入失败的连接会被关闭并注销
+func (h *Hub) SendToUser(userID uint, data []byte) {
+	h.mu.RLock()
+	clients := make([]*Client, 0, len(h.clients[userID]))
+	for client := range h.clients[userID] {
+		clients = append(clients, client)
+	}
+	h.mu.RUnlock()
+
+	for _, client := range clients {
+		if err := client.Write(data); err != nil {
+			log.Println("Write message error:", err)
+			client.conn.Close()
+			h.Unregister(userID, client)
+		}
+	}
+}
+
+// SendToUsers 向多个用户发送原始消息
+func (h *Hub) SendToUsers(userIDs []uint, data []byte) {
+	for _, userID := range userIDs {
+		h.SendToUser(userID, data)
+	}
+}
+
+// PushEvent 向多个用户推送事件
+func (h *Hub) PushEvent(userIDs []uint, eventType string, data interface{}) {
+	payload, err := json.Marshal(Event{Type: eventType, Data: data})
+	if err != nil {
+		log.Println("Marshal event error:", err)
+		return
+	}
+	h.SendToUsers(userIDs, payload)
+}
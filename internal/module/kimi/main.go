@@ -7,8 +7,10 @@ import (
 	"context"
 	"encoding/json"
 	"im-system/internal/config"
+	"im-system/internal/middle"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -54,6 +56,13 @@ var KimiClient = NewClient[moonshot](moonshot{
 })
 
 func HandleWebSocket(c *gin.Context) {
+	// 连接由 AuthWSMiddleware 鉴权，用户 ID 取自 token
+	currentUserID, ok := middle.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未登录"})
+		return
+	}
+
 	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Println("WebSocket upgrade failed:", err)
@@ -63,8 +72,7 @@ func HandleWebSocket(c *gin.Context) {
 
 	ctx := context.Background()
 
-	// todo: 假设从请求中获取用户 ID 或会话 ID
-	userID := "user_123" // 这里可以根据实际情况从请求中获取用户 ID 或生成会话 ID
+	userID := strconv.FormatUint(uint64(currentUserID), 10)
 
 	// 定义用户消息结构
 	type UserMessage struct {
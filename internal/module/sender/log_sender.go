@@ -0,0 +1,38 @@
+package sender
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// LogSender 把消息追加写入本地文件，用于本地开发和测试环境，不会真正发送
+type LogSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLogSender 创建写入 path 的 LogSender
+func NewLogSender(path string) *LogSender {
+	return &LogSender{path: path}
+}
+
+// Send 将消息追加写入文件
+func (s *LogSender) Send(to, subject, content string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "%s\tto=%s\tsubject=%s\t%s\n", time.Now().Format(time.RFC3339), to, subject, content)
+	return err
+}
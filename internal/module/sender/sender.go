@@ -0,0 +1,7 @@
+package sender
+
+// Sender 验证码等消息的发送渠道，新的渠道（短信服务商等）实现该接口即可接入
+type Sender interface {
+	// Send 向 to 发送一条消息，不支持标题的渠道忽略 subject
+	Send(to, subject, content string) error
+}
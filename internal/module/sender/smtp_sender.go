@@ -0,0 +1,62 @@
+package sender
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+)
+
+// SMTPSender 通过 SMTP 发送邮件，服务器支持时自动使用 STARTTLS
+type SMTPSender struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPSender 创建 SMTPSender，username 为空时不进行认证
+func NewSMTPSender(host string, port int, username, password, from string) *SMTPSender {
+	return &SMTPSender{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// Send 发送一封纯文本邮件
+func (s *SMTPSender) Send(to, subject, content string) error {
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+	addr := net.JoinHostPort(s.host, strconv.Itoa(s.port))
+	return smtp.SendMail(addr, auth, s.from, []string{to}, s.buildMessage(to, subject, content))
+}
+
+// buildMessage 构造邮件内容，标题和正文都按 UTF-8 编码，避免中文乱码
+func (s *SMTPSender) buildMessage(to, subject, content string) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: base64\r\n")
+	msg.WriteString("\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(content))
+	// base64 正文每行不超过 76 个字符
+	for len(encoded) > 76 {
+		msg.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	msg.WriteString(encoded + "\r\n")
+	return msg.Bytes()
+}
@@ -0,0 +1,107 @@
+package usercache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"im-system/internal/config"
+	"im-system/internal/model/db"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrUserNotFound 用户不存在，通常是账号已被删除
+var ErrUserNotFound = errors.New("用户不存在")
+
+// CachedUser 缓存在 Redis 中的用户信息，只包含资料字段，不包含密码哈希、两步验证密钥和系统角色
+type CachedUser struct {
+	ID          uint      `json:"id"`
+	PhoneNumber string    `json:"phone_number"`
+	Email       *string   `json:"email"`
+	Handle      *string   `json:"handle"`
+	Username    string    `json:"username"`
+	AvatarURL   string    `json:"avatar_url"`
+	Bio         string    `json:"bio"`
+	Gender      string    `json:"gender"`
+	Address     string    `json:"address"`
+	City        string    `json:"city"`
+	State       string    `json:"state"`
+	Country     string    `json:"country"`
+	PostalCode  string    `json:"postal_code"`
+	DateOfBirth string    `json:"date_of_birth"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// FromUser 从用户表记录生成缓存的用户信息
+func FromUser(user db.User) CachedUser {
+	return CachedUser{
+		ID:          user.ID,
+		PhoneNumber: user.PhoneNumber,
+		Email:       user.Email,
+		Handle:      user.Handle,
+		Username:    user.Username,
+		AvatarURL:   user.AvatarURL,
+		Bio:         user.Bio,
+		Gender:      user.Gender,
+		Address:     user.Address,
+		City:        user.City,
+		State:       user.State,
+		Country:     user.Country,
+		PostalCode:  user.PostalCode,
+		DateOfBirth: user.DateOfBirth,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+	}
+}
+
+// GetRedisUserCacheKey 获取用户信息缓存的 Redis key
+func GetRedisUserCacheKey(userID uint) string {
+	return fmt.Sprintf("user:cache:%d", userID)
+}
+
+// Get 获取用户信息，优先读取 Redis，缓存未命中或 Redis 不可用时从 MySQL 加载并写入缓存
+func Get(userID uint) (CachedUser, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	key := GetRedisUserCacheKey(userID)
+	if cached, err := config.RedisClient.Get(ctx, key).Result(); err == nil {
+		var user CachedUser
+		if err := json.Unmarshal([]byte(cached), &user); err == nil {
+			return user, nil
+		}
+	}
+
+	var record db.User
+	if err := db.DB.First(&record, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return CachedUser{}, ErrUserNotFound
+		}
+		return CachedUser{}, err
+	}
+	user := FromUser(record)
+
+	marshal, err := json.Marshal(user)
+	if err != nil {
+		return user, nil
+	}
+	// 缓存设置了较短的有效期，即使与并发的修改交错写入了旧数据，也会在有效期后自动纠正
+	ttl := time.Duration(config.Auth.UserCacheTTL) * time.Second
+	if err := config.RedisClient.Set(ctx, key, marshal, ttl).Err(); err != nil {
+		config.Logger.Error(err)
+	}
+	return user, nil
+}
+
+// Invalidate 删除用户信息缓存，修改用户表后调用，下次读取时从 MySQL 重新加载
+func Invalidate(userID uint) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := config.RedisClient.Del(ctx, GetRedisUserCacheKey(userID)).Err(); err != nil {
+		config.Logger.Error(err)
+	}
+}
@@ -2,6 +2,7 @@ package router
 
 import (
 	"im-system/internal/handler"
+	"im-system/internal/middle"
 	"im-system/internal/module/kimi"
 
 	"github.com/gin-gonic/gin"
@@ -9,68 +10,125 @@ import (
 
 // RegisterRoutes 注册所有路由
 func RegisterRoutes(r *gin.Engine, userHandler *handler.UserHandler,
+	verificationHandler *handler.VerificationHandler,
 	friendHandler *handler.FriendHandler,
 	notificationHandler *handler.NotificationHandler,
 	friendGroupHandler *handler.FriendGroupHandler,
 	groupHandler *handler.GroupHandler,
 	webSocketHandler *handler.WebSocketHandler,
-	chatSummaryHandler *handler.ChatSummaryHandler) {
+	chatSummaryHandler *handler.ChatSummaryHandler,
+	adminHandler *handler.AdminHandler) {
 
 	// 配置静态文件服务
 	r.Static("/static", "./static")
 
 	imGroup := r.Group("/im-server")
-	// 注册登录模块
+
+	// 公开接口，不需要登录
+	public := imGroup.Group("")
+	{
+		public.POST("/register", userHandler.Register)            // 注册接口
+		public.POST("/login", userHandler.Login)                  // 登录接口
+		public.POST("/auth/refresh", userHandler.RefreshToken)    // 刷新访问令牌
+		public.POST("/verify/send", verificationHandler.SendCode) // 发送注册、重置密码验证码
+		public.POST("/password/reset", userHandler.ResetPassword) // 通过验证码重置密码
+		public.POST("/login/2fa", userHandler.LoginTwoFactor)     // 登录第二步，校验两步验证码
+	}
+
+	// WebSocket 接口，升级连接之前通过 token 鉴权
+	ws := imGroup.Group("", middle.AuthWSMiddleware())
+	{
+		ws.GET("/ws", kimi.HandleWebSocket)                   // AI 助手
+		ws.GET("/private/chat", webSocketHandler.SendMessage) // 发送消息
+	}
+
+	// 需要登录的接口
+	authed := imGroup.Group("", middle.AuthMiddleware())
 	{
-		imGroup.POST("/register", userHandler.Register)          // 注册接口
-		imGroup.POST("/login", userHandler.Login)                // 登录接口
-		imGroup.POST("/logout", userHandler.Logout)              // 退出登录接口
-		imGroup.GET("/user/check_token", userHandler.CheckToken) // 检查token是否有效
+		authed.POST("/logout", userHandler.Logout)                                   // 退出登录接口
+		authed.GET("/user/check_token", userHandler.CheckToken)                      // 检查token是否有效
+		authed.GET("/user/sessions", userHandler.GetSessions)                        // 获取登录会话列表
+		authed.POST("/user/sessions/revoke", userHandler.RevokeSession)              // 注销指定会话
+		authed.POST("/user/sessions/revoke_all", userHandler.RevokeAllSessions)      // 注销所有会话
+		authed.POST("/user/verify/send", verificationHandler.SendUserCode)           // 发送修改手机号、邮箱验证码
+		authed.POST("/user/change_phone", userHandler.ChangePhone)                   // 修改手机号
+		authed.POST("/user/change_email", userHandler.ChangeEmail)                   // 修改邮箱
+		authed.POST("/user/change_password", userHandler.ChangePassword)             // 修改密码
+		authed.POST("/user/2fa/enroll", userHandler.EnrollTwoFactor)                 // 获取两步验证密钥
+		authed.POST("/user/2fa/confirm", userHandler.ConfirmTwoFactor)               // 确认开启两步验证
+		authed.POST("/user/2fa/disable", userHandler.DisableTwoFactor)               // 关闭两步验证
+		authed.POST("/user/2fa/recovery_codes", userHandler.RegenerateRecoveryCodes) // 重新生成恢复码
 		// user 模块
-		imGroup.GET("/user/userInfo", userHandler.GetUserInfo)        // 获取用户信息
-		imGroup.POST("/user/add_friend", userHandler.AddFriend)       // 添加好友的路由
-		imGroup.POST("/user/update", userHandler.UpdateUserInfo)      // 更新用户信息
-		imGroup.POST("/user/query", userHandler.QueryUserAndGroup)    // 查询用户和群聊信息
-		imGroup.POST("/user/del_friend", userHandler.DeleteFriend)    // 删除好友
-		imGroup.POST("/user/upload_avatar", userHandler.UploadAvatar) // 上传头像
+		authed.GET("/user/userInfo", userHandler.GetUserInfo)        // 获取用户信息
+		authed.POST("/user/add_friend", userHandler.AddFriend)       // 添加好友的路由
+		authed.POST("/user/update", userHandler.UpdateUserInfo)      // 更新用户信息
+		authed.POST("/user/query", userHandler.QueryUserAndGroup)    // 查询用户和群聊信息
+		authed.POST("/user/del_friend", userHandler.DeleteFriend)    // 删除好友
+		authed.POST("/user/block", userHandler.BlockUser)            // 拉黑用户
+		authed.POST("/user/unblock", userHandler.UnblockUser)        // 取消拉黑用户
+		authed.POST("/user/upload_avatar", userHandler.UploadAvatar) // 上传头像
 
 		// 使用 friends 前缀
-		friendsGroup := imGroup.Group("/friends")
+		friendsGroup := authed.Group("/friends")
 		friendsGroup.GET("/user/groups", friendHandler.GetUserFriendAllFriends) // 获取好友分组的路由
 		friendsGroup.GET("/user/friend_groups", friendHandler.GetFriendGroups)  // 获取用户的好友列表(用于好友模块)
 		friendsGroup.GET("/usr/friends_chat", friendHandler.GetUserFriendsChat) // 获取用户的好友列表(用于私聊模块)
 		friendsGroup.GET("/all", friendHandler.GetUserFriends)                  // 获取用户的好友列表(用于好友模块)
 		friendsGroup.POST("/update", friendHandler.UpdateFriendGroup)           // 修改用户好友的分组
+		friendsGroup.GET("/suggestions", friendHandler.GetFriendSuggestions)    // 获取可能认识的人
+		friendsGroup.POST("/settings", friendHandler.UpdateFriendSettings)      // 修改好友的个性化设置
 
 		// notifications 通知模块
-		imGroup.GET("/notifications", notificationHandler.GetNotifications)                              // 获取通知的路由
-		imGroup.POST("/notifications/:notification_id", notificationHandler.HandleFriendRequest)         // 处理好友请求
-		imGroup.GET("/notifications/get/sent_notifications", notificationHandler.GetSentNotifications)   // 获取已发送的好友请求
-		imGroup.GET("/notifications/friend_requests", notificationHandler.GetFriendRequestNotifications) // 获取好友请求通知
+		authed.GET("/notifications", notificationHandler.GetNotifications)                              // 获取通知的路由
+		authed.POST("/notifications/:notification_id", notificationHandler.HandleFriendRequest)         // 处理好友请求
+		authed.GET("/notifications/get/sent_notifications", notificationHandler.GetSentNotifications)   // 获取已发送的好友请求
+		authed.GET("/notifications/friend_requests", notificationHandler.GetFriendRequestNotifications) // 获取好友请求通知
+		authed.GET("/notifications/unread_count", notificationHandler.GetUnreadCount)                   // 获取未读通知数
+		authed.POST("/notifications/read", notificationHandler.MarkAsRead)                              // 标记通知已读
+		authed.POST("/notifications/read_all", notificationHandler.MarkAllAsRead)                       // 全部标记已读
+		authed.POST("/notifications/delete", notificationHandler.DeleteNotifications)                   // 删除通知
+		authed.POST("/notifications/clear", notificationHandler.ClearNotifications)                     // 清空已处理的通知
 
 		// friend_groups 好友分组模块
-		imGroup.POST("/friend_groups", friendGroupHandler.CreateFriendGroup)  // 创建好友分组
-		imGroup.GET("/friend_groups", friendGroupHandler.GetUserFriendGroups) // 获取用户的所有好友分组
+		authed.POST("/friend_groups", friendGroupHandler.CreateFriendGroup)           // 创建好友分组
+		authed.GET("/friend_groups", friendGroupHandler.GetUserFriendGroups)          // 获取用户的所有好友分组
+		authed.POST("/friend_groups/rename", friendGroupHandler.RenameFriendGroup)    // 重命名好友分组
+		authed.POST("/friend_groups/reorder", friendGroupHandler.ReorderFriendGroups) // 调整好友分组的顺序
+		authed.POST("/friend_groups/delete", friendGroupHandler.DeleteFriendGroup)    // 删除好友分组
+		authed.POST("/friend_groups/move", friendGroupHandler.MoveFriends)            // 批量移动好友到指定分组
 
 		// 群组模块
-		imGroup.POST("/groups", groupHandler.CreateGroup)                         // 创建群组
-		imGroup.POST("/groups/query", groupHandler.QueryGroups)                   // 查询群组
-		imGroup.GET("/groups/user", groupHandler.GetUserGroups)                   // 获取用户所在的群聊
-		imGroup.GET("/groups/my_groups", groupHandler.GetMyAllGroups)             // 获取用户的所有群聊
-		imGroup.GET("/groups/members", groupHandler.GetGroupMembers)              // 获取群聊的所有成员
-		imGroup.POST("/groups/invite", groupHandler.InviteGroup)                  // 邀请好友加入群聊
-		imGroup.POST("/groups/update_member_role", groupHandler.UpdateMemberRole) // 更新群成员角色
-		imGroup.POST("/groups/remove_member", groupHandler.RemoveMember)          // 移除群成员
-		imGroup.POST("/groups/quit", groupHandler.QuitGroup)                      // 退出群聊
-		imGroup.POST("/groups/update", groupHandler.UpdateGroup)                  // 更新群聊信息
-
-		// WebSocket 路由，使用鉴权中间件
-		imGroup.GET("/ws", kimi.HandleWebSocket)
+		authed.POST("/groups", groupHandler.CreateGroup)                               // 创建群组
+		authed.POST("/groups/query", groupHandler.QueryGroups)                         // 查询群组
+		authed.GET("/groups/user", groupHandler.GetUserGroups)                         // 获取用户所在的群聊
+		authed.GET("/groups/my_groups", groupHandler.GetMyAllGroups)                   // 获取用户的所有群聊
+		authed.GET("/groups/members", groupHandler.GetGroupMembers)                    // 获取群聊的所有成员
+		authed.POST("/groups/invite", groupHandler.InviteGroup)                        // 邀请好友加入群聊
+		authed.POST("/groups/update_member_role", groupHandler.UpdateMemberRole)       // 更新群成员角色
+		authed.POST("/groups/remove_member", groupHandler.RemoveMember)                // 移除群成员
+		authed.POST("/groups/quit", groupHandler.QuitGroup)                            // 退出群聊
+		authed.POST("/groups/update", groupHandler.UpdateGroup)                        // 更新群聊信息
+		authed.POST("/groups/invite_links", groupHandler.CreateInviteLink)             // 创建群邀请链接
+		authed.GET("/groups/invite_links", groupHandler.GetInviteLinks)                // 获取群邀请链接
+		authed.POST("/groups/invite_links/revoke", groupHandler.RevokeInviteLink)      // 撤销群邀请链接
+		authed.POST("/groups/join_by_link", groupHandler.JoinGroupByLink)              // 通过邀请码加入群聊
+		authed.POST("/groups/member/nickname", groupHandler.SetMemberNickname)         // 修改自己的群昵称
+		authed.POST("/groups/member/title", groupHandler.SetMemberTitle)               // 授予群成员称号
+		authed.POST("/groups/announcements", groupHandler.PublishAnnouncement)         // 发布群公告
+		authed.GET("/groups/announcements", groupHandler.GetAnnouncements)             // 获取群公告历史
+		authed.POST("/groups/announcements/pin", groupHandler.PinAnnouncement)         // 置顶或取消置顶群公告
+		authed.POST("/groups/announcements/delete", groupHandler.DeleteAnnouncement)   // 删除群公告
+		authed.POST("/groups/announcements/ack", groupHandler.AcknowledgeAnnouncement) // 确认已读群公告
+		authed.GET("/groups/announcements/acks", groupHandler.GetAnnouncementAcks)     // 获取群公告确认情况
+		authed.GET("/groups/audit", groupHandler.GetAuditLogs)                         // 获取群组审计日志
 
 		// 聊天模块
-		imGroup.GET("/private/chat", webSocketHandler.SendMessage) // 发送消息
+		authed.POST("/chat/summary", chatSummaryHandler.HandleChatSummary) // 生成聊天总结
+	}
 
-		// 添加新路由
-		imGroup.POST("/chat/summary", chatSummaryHandler.HandleChatSummary)
+	// 管理员接口
+	admin := imGroup.Group("/admin", middle.AuthMiddleware(), middle.AdminMiddleware())
+	{
+		admin.POST("/friendships/repair", adminHandler.RepairFriendships) // 修复不对称的好友关系
 	}
 }
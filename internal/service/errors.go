@@ -0,0 +1,19 @@
+package service
+
+// ForbiddenError 没有权限执行操作，handler 层据此返回 403
+type ForbiddenError struct {
+	Message string
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Message
+}
+
+// TooManyRequestsError 操作过于频繁或被临时锁定，handler 层据此返回 429
+type TooManyRequestsError struct {
+	Message string
+}
+
+func (e *TooManyRequestsError) Error() string {
+	return e.Message
+}
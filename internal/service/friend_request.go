@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"im-system/internal/config"
+	"im-system/internal/model/db"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GetRedisFriendRequestRateKey 获取好友请求限流计数的 Redis key
+func GetRedisFriendRequestRateKey(userId uint) string {
+	return fmt.Sprintf("friend:request:rate:%d", userId)
+}
+
+// checkFriendRequestRate 记录一次好友请求并检查时间窗口内的请求数是否超出限制，Redis 不可用时不限流。
+// 只应在请求通过校验、即将发出时调用，重复或无效的请求不占用次数
+func checkFriendRequestRate(userId uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	key := GetRedisFriendRequestRateKey(userId)
+	count, err := config.RedisClient.Incr(ctx, key).Result()
+	if err != nil {
+		config.Logger.Error(err)
+		return nil
+	}
+	if count == 1 {
+		window := time.Duration(config.Friend.RequestRateWindow) * time.Second
+		if err := config.RedisClient.Expire(ctx, key, window).Err(); err != nil {
+			config.Logger.Error(err)
+		}
+	}
+	if count > int64(config.Friend.RequestRateLimit) {
+		return errors.New("好友请求过于频繁，请稍后再试")
+	}
+	return nil
+}
+
+// friendRequestExpired 判断在 sentAt 发出的好友请求是否已过期
+func friendRequestExpired(sentAt time.Time) bool {
+	expire := time.Duration(config.Friend.RequestExpireHours) * time.Hour
+	return time.Since(sentAt) > expire
+}
+
+// expireFriendRequests 将过期的好友请求通知标记为 expired，对应的待处理好友关系标记为已删除
+func expireFriendRequests(tx *gorm.DB) error {
+	before := time.Now().Add(-time.Duration(config.Friend.RequestExpireHours) * time.Hour)
+	return tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&db.Notification{}).
+			Where("type = ? AND status = ? AND created_at < ?", "friend_request", "pending", before).
+			Update("status", "expired").Error; err != nil {
+			return err
+		}
+		// pending -> deleted 是允许的状态切换，这里批量更新
+		return tx.Model(&db.Friendship{}).
+			Where("status = ? AND updated_at < ?", FriendshipPending, before).
+			Update("status", FriendshipDeleted).Error
+	})
+}
+
+// acceptMutualRequest 双方互相发送了好友请求，直接接受对方的请求。
+// friendship 为自己一方的好友关系，reverse 为对方的待处理请求，notification 返回对方发来的好友请求通知
+func acceptMutualRequest(tx *gorm.DB, friendship, reverse *db.Friendship, notification *db.Notification) error {
+	if err := transitFriendship(tx, reverse, FriendshipAccepted); err != nil {
+		return err
+	}
+
+	if friendship.Remark == "" {
+		var friend db.User
+		if err := tx.First(&friend, friendship.FriendID).Error; err != nil {
+			return err
+		}
+		friendship.Remark = friend.Username
+	}
+	if err := transitFriendship(tx, friendship, FriendshipAccepted); err != nil {
+		return err
+	}
+
+	// 对方的请求通知标记为已接受，找不到时说明通知已被清理
+	err := tx.Where("sender_id = ? AND receiver_id = ? AND type = ? AND status = ?", reverse.UserID, reverse.FriendID, "friend_request", "pending").
+		Order("id desc").First(notification).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	notification.IsRead = true
+	notification.Status = "accepted"
+	return tx.Save(notification).Error
+}
@@ -7,11 +7,16 @@ import (
 	"im-system/internal/model/db"
 	"im-system/internal/model/dto"
 	"im-system/internal/model/vo"
+	"im-system/internal/module/hub"
 	"log"
+	"sort"
+	"unicode/utf8"
 
 	"gorm.io/gorm"
 )
 
+const maxRemarkLength = 32 // 好友备注最大长度
+
 type FriendService struct {
 	db *gorm.DB
 }
@@ -31,12 +36,15 @@ type Message struct {
 	Avatar      string `json:"avatar"`
 	Content     string `json:"content"`
 	MessageType string `json:"messageType"` // "private" 或 "group"
+	Nickname    string `json:"nickname"`    // 发送者的群昵称
+	Title       string `json:"title"`       // 发送者的群称号
+	Level       int    `json:"level"`       // 发送者的群等级
 }
 
 // GetUserFriendsGroups 获取用户的好友
 func (s *FriendService) GetUserFriendsGroups(userID uint) ([]db.FriendGroup, error) {
 	var groups []db.FriendGroup
-	if err := s.db.Where("user_id = ?", userID).Find(&groups).Error; err != nil {
+	if err := s.db.Where("user_id = ?", userID).Order("sort_order, id").Find(&groups).Error; err != nil {
 		return nil, err
 	}
 	return groups, nil
@@ -47,7 +55,7 @@ func (s *FriendService) GetFriendGroupsWithMembers(userID uint) ([]vo.FriendShip
 	var resp []vo.FriendShipGroupVO
 
 	var groups []db.FriendGroup
-	if err := s.db.Where("user_id = ?", userID).Find(&groups).Error; err != nil {
+	if err := s.db.Where("user_id = ?", userID).Order("sort_order, id").Find(&groups).Error; err != nil {
 		return nil, err
 	}
 
@@ -73,17 +81,25 @@ func (s *FriendService) GetFriendGroupsWithMembers(userID uint) ([]vo.FriendShip
 					ID:          user.ID,
 					Username:    user.Username,
 					AvatarURL:   user.AvatarURL,
-					Email:       user.Email,
+					Email:       stringValue(user.Email),
 					PhoneNumber: user.PhoneNumber,
 					Bio:         user.Bio,
 					Gender:      user.Gender,
 					City:        user.City,
 					Remark:      friend.Remark, // 添加备注信息
+					Status:      s.friendStatus(userID, friend.FriendID),
+					Starred:     friend.Starred,
+					Muted:       friend.Muted,
+					Pinned:      friend.Pinned,
 					CreatedAt:   user.CreatedAt,
 					UpdatedAt:   user.UpdatedAt,
 				})
 			}
 		}
+		// 特别关心的好友排在前面
+		sort.SliceStable(resp[i].Members, func(a, b int) bool {
+			return resp[i].Members[a].Starred && !resp[i].Members[b].Starred
+		})
 	}
 
 	return resp, nil
@@ -121,6 +137,8 @@ func (s *FriendService) GetUserFriendsChat(userId uint) ([]vo.ChatVO, error) {
 				Avatar:      user.AvatarURL,
 				LastMessage: "",
 				Type:        "personal",
+				Pinned:      friend.Pinned,
+				Muted:       friend.Muted,
 				// todo: 查询和好友所有的聊天记录
 				Messages: msgs,
 			})
@@ -158,6 +176,10 @@ func (s *FriendService) GetUserFriendsChat(userId uint) ([]vo.ChatVO, error) {
 			Messages: msgs,
 		})
 	}
+	// 置顶的会话排在前面
+	sort.SliceStable(resp, func(i, j int) bool {
+		return resp[i].Pinned && !resp[j].Pinned
+	})
 
 	return resp, nil
 }
@@ -180,17 +202,25 @@ func (s *FriendService) GetUserFriends(userID uint) ([]vo.FriendVO, error) {
 				ID:          user.ID,
 				Name:        user.Username,
 				Avatar:      user.AvatarURL,
-				Status:      "离线", // todo: 这里需要根据实际情况获取用户状态
-				Email:       user.Email,
+				Status:      s.friendStatus(userID, friend.FriendID),
+				Email:       stringValue(user.Email),
 				PhoneNumber: user.PhoneNumber,
 				Bio:         user.Bio,
 				Gender:      user.Gender,
 				City:        user.City,
+				Remark:      friend.Remark,
+				Starred:     friend.Starred,
+				Muted:       friend.Muted,
+				Pinned:      friend.Pinned,
 				CreatedAt:   user.CreatedAt,
 				UpdatedAt:   user.UpdatedAt,
 			})
 		}
 	}
+	// 特别关心的好友排在前面
+	sort.SliceStable(resp, func(i, j int) bool {
+		return resp[i].Starred && !resp[j].Starred
+	})
 
 	return resp, nil
 }
@@ -210,6 +240,9 @@ func reverseMessage(messages []db.Message) ([]vo.MessageVO, error) {
 			SenderID:   msg.SenderId,
 			SenderName: msg.SenderName,
 			Avatar:     msg.Avatar,
+			Nickname:   msg.Nickname,
+			Title:      msg.Title,
+			Level:      msg.Level,
 			CreatedAt:  messages[i].CreatedAt,
 		})
 	}
@@ -288,3 +321,52 @@ func (s *FriendService) UpdateFriendGroup(userID uint, dto dto.UpdateFriendGroup
 
 	return nil
 }
+
+// UpdateFriendSettings 修改对好友的个性化设置，只修改传入的字段
+func (s *FriendService) UpdateFriendSettings(userID uint, settingsDTO dto.FriendSettingsDTO) error {
+	var friendship db.Friendship
+	if err := s.db.Where("user_id = ? AND friend_id = ? AND status = ?", userID, settingsDTO.FriendID, "accepted").First(&friendship).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("好友关系不存在")
+		}
+		return err
+	}
+
+	updates := map[string]interface{}{}
+	if settingsDTO.Remark != nil {
+		if utf8.RuneCountInString(*settingsDTO.Remark) > maxRemarkLength {
+			return errors.New("好友备注过长")
+		}
+		updates["remark"] = *settingsDTO.Remark
+	}
+	if settingsDTO.Starred != nil {
+		updates["starred"] = *settingsDTO.Starred
+	}
+	if settingsDTO.Muted != nil {
+		updates["muted"] = *settingsDTO.Muted
+	}
+	if settingsDTO.Pinned != nil {
+		updates["pinned"] = *settingsDTO.Pinned
+	}
+	if settingsDTO.HidePresence != nil {
+		updates["hide_presence"] = *settingsDTO.HidePresence
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return s.db.Model(&friendship).Updates(updates).Error
+}
+
+// friendStatus 获取好友的在线状态，好友对自己隐藏在线状态时显示为离线
+func (s *FriendService) friendStatus(userID, friendID uint) string {
+	if !hub.Default.IsOnline(friendID) {
+		return "离线"
+	}
+	var hidden int64
+	if err := s.db.Model(&db.Friendship{}).
+		Where("user_id = ? AND friend_id = ? AND hide_presence = ?", friendID, userID, true).
+		Count(&hidden).Error; err != nil || hidden > 0 {
+		return "离线"
+	}
+	return "在线"
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"im-system/internal/model/db"
+	"im-system/internal/model/vo"
+	"sort"
+)
+
+const (
+	defaultSuggestionLimit = 20  // 默认的推荐人数
+	maxSuggestionLimit     = 50  // 最多推荐的人数
+	sameCityCandidateLimit = 100 // 同城候选人的最大查询数
+
+	mutualFriendWeight = 3 // 每个共同好友的分数
+	sharedGroupWeight  = 2 // 每个共同群聊的分数
+	sameCityWeight     = 1 // 同城的分数
+)
+
+// suggestionExcludedStatuses 这些状态的好友关系对应的用户不会出现在推荐中。
+// 已删除、被拒绝或已过期的关系不排除，这些用户仍然可以被推荐
+var suggestionExcludedStatuses = map[string]bool{
+	FriendshipAccepted: true,
+	FriendshipPending:  true,
+	FriendshipBlocked:  true,
+}
+
+// suggestionCount 按用户统计的数量
+type suggestionCount struct {
+	UserID uint
+	Count  int
+}
+
+// GetFriendSuggestions 推荐可能认识的人，按共同好友数、共同群聊数和是否同城综合排序。
+// 已是好友、已发送或收到请求、已拉黑的用户都不会出现在推荐中
+func (s *FriendService) GetFriendSuggestions(userID uint, limit int) ([]vo.FriendSuggestionVO, error) {
+	if limit <= 0 {
+		limit = defaultSuggestionLimit
+	}
+	if limit > maxSuggestionLimit {
+		limit = maxSuggestionLimit
+	}
+	resp := make([]vo.FriendSuggestionVO, 0)
+
+	var me db.User
+	if err := s.db.First(&me, userID).Error; err != nil {
+		return nil, err
+	}
+
+	// 已是好友、有待处理请求或拉黑关系的用户需要排除
+	var related []db.Friendship
+	if err := s.db.Where("user_id = ? OR friend_id = ?", userID, userID).Find(&related).Error; err != nil {
+		return nil, err
+	}
+	excluded := suggestionExclusions(userID, related)
+
+	// 共同好友：我的好友的好友
+	var mutuals []suggestionCount
+	if err := s.db.Table("friendships AS f1").
+		Select("f2.friend_id AS user_id, COUNT(*) AS count").
+		Joins("JOIN friendships AS f2 ON f2.user_id = f1.friend_id").
+		Where("f1.user_id = ? AND f1.status = ? AND f2.status = ?", userID, "accepted", "accepted").
+		Group("f2.friend_id").
+		Scan(&mutuals).Error; err != nil {
+		return nil, err
+	}
+
+	// 共同群聊：和我在同一个群里的人
+	var shared []suggestionCount
+	if err := s.db.Table("group_members AS m1").
+		Select("m2.user_id AS user_id, COUNT(*) AS count").
+		Joins("JOIN group_members AS m2 ON m2.group_id = m1.group_id").
+		Where("m1.user_id = ? AND m2.user_id <> ?", userID, userID).
+		Group("m2.user_id").
+		Scan(&shared).Error; err != nil {
+		return nil, err
+	}
+
+	// 同城用户
+	var sameCityIDs []uint
+	if me.City != "" {
+		if err := s.db.Model(&db.User{}).Where("city = ? AND id <> ?", me.City, userID).
+			Limit(sameCityCandidateLimit).Pluck("id", &sameCityIDs).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	ranked := rankFriendSuggestions(excluded, mutuals, shared, sameCityIDs, limit)
+	if len(ranked) == 0 {
+		return resp, nil
+	}
+
+	// 批量查询用户信息
+	ids := make([]uint, 0, len(ranked))
+	for _, suggestion := range ranked {
+		ids = append(ids, suggestion.ID)
+	}
+	var users []db.User
+	if err := s.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	userMap := make(map[uint]db.User, len(users))
+	for _, user := range users {
+		userMap[user.ID] = user
+	}
+	for _, suggestion := range ranked {
+		user, ok := userMap[suggestion.ID]
+		if !ok {
+			continue
+		}
+		suggestion.Username = user.Username
+		suggestion.AvatarURL = user.AvatarURL
+		suggestion.Bio = user.Bio
+		suggestion.Gender = user.Gender
+		suggestion.City = user.City
+		resp = append(resp, *suggestion)
+	}
+	return resp, nil
+}
+
+// suggestionExclusions 根据好友关系计算不参与推荐的用户，包括用户自己
+func suggestionExclusions(userID uint, related []db.Friendship) map[uint]bool {
+	excluded := map[uint]bool{userID: true}
+	for _, friendship := range related {
+		if !suggestionExcludedStatuses[friendship.Status] {
+			continue
+		}
+		excluded[friendship.UserID] = true
+		excluded[friendship.FriendID] = true
+	}
+	return excluded
+}
+
+// rankFriendSuggestions 合并共同好友、共同群聊和同城候选人，计算分数并排序，最多返回 limit 个
+func rankFriendSuggestions(excluded map[uint]bool, mutuals, shared []suggestionCount, sameCityIDs []uint, limit int) []*vo.FriendSuggestionVO {
+	candidates := make(map[uint]*vo.FriendSuggestionVO)
+	candidate := func(id uint) *vo.FriendSuggestionVO {
+		if excluded[id] {
+			return nil
+		}
+		if candidates[id] == nil {
+			candidates[id] = &vo.FriendSuggestionVO{ID: id}
+		}
+		return candidates[id]
+	}
+	for _, mutual := range mutuals {
+		if suggestion := candidate(mutual.UserID); suggestion != nil {
+			suggestion.MutualFriends = mutual.Count
+		}
+	}
+	for _, group := range shared {
+		if suggestion := candidate(group.UserID); suggestion != nil {
+			suggestion.SharedGroups = group.Count
+		}
+	}
+	for _, id := range sameCityIDs {
+		if suggestion := candidate(id); suggestion != nil {
+			suggestion.SameCity = true
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	// 计算分数并排序，分数相同时共同好友多的优先
+	ranked := make([]*vo.FriendSuggestionVO, 0, len(candidates))
+	for _, suggestion := range candidates {
+		suggestion.Score = suggestion.MutualFriends*mutualFriendWeight + suggestion.SharedGroups*sharedGroupWeight
+		if suggestion.SameCity {
+			suggestion.Score += sameCityWeight
+		}
+		ranked = append(ranked, suggestion)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		if ranked[i].MutualFriends != ranked[j].MutualFriends {
+			return ranked[i].MutualFriends > ranked[j].MutualFriends
+		}
+		return ranked[i].ID < ranked[j].ID
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"im-system/internal/model/db"
+	"testing"
+)
+
+func TestSuggestionExclusions(t *testing.T) {
+	const me = 1
+	related := []db.Friendship{
+		{UserID: me, FriendID: 2, Status: FriendshipAccepted},
+		{UserID: me, FriendID: 3, Status: FriendshipPending},
+		{UserID: 4, FriendID: me, Status: FriendshipPending},
+		{UserID: me, FriendID: 5, Status: FriendshipBlocked},
+		{UserID: 6, FriendID: me, Status: FriendshipBlocked},
+		{UserID: me, FriendID: 7, Status: FriendshipDeleted},
+		{UserID: 8, FriendID: me, Status: FriendshipRejected},
+		{UserID: me, FriendID: 9, Status: FriendshipRejected},
+	}
+	excluded := suggestionExclusions(me, related)
+
+	for id, want := range map[uint]bool{me: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: false, 8: false, 9: false} {
+		if excluded[id] != want {
+			t.Errorf("excluded[%d] = %v, want %v", id, excluded[id], want)
+		}
+	}
+}
+
+func TestDeletedOrRejectedRelationIsStillSuggested(t *testing.T) {
+	const me = 1
+	related := []db.Friendship{
+		{UserID: me, FriendID: 2, Status: FriendshipAccepted},
+		{UserID: me, FriendID: 3, Status: FriendshipDeleted},
+		{UserID: 4, FriendID: me, Status: FriendshipRejected},
+	}
+	mutuals := []suggestionCount{{UserID: 2, Count: 1}, {UserID: 3, Count: 2}, {UserID: 4, Count: 1}}
+
+	ranked := rankFriendSuggestions(suggestionExclusions(me, related), mutuals, nil, nil, defaultSuggestionLimit)
+
+	got := make([]uint, 0, len(ranked))
+	for _, suggestion := range ranked {
+		got = append(got, suggestion.ID)
+	}
+	if len(got) != 2 || got[0] != 3 || got[1] != 4 {
+		t.Fatalf("suggested %v, want [3 4]", got)
+	}
+}
+
+func TestRankFriendSuggestions(t *testing.T) {
+	excluded := map[uint]bool{1: true}
+	mutuals := []suggestionCount{{UserID: 2, Count: 1}, {UserID: 3, Count: 1}}
+	shared := []suggestionCount{{UserID: 3, Count: 1}, {UserID: 4, Count: 2}}
+	sameCity := []uint{1, 2, 5}
+
+	ranked := rankFriendSuggestions(excluded, mutuals, shared, sameCity, 3)
+
+	want := []struct {
+		id    uint
+		score int
+	}{
+		{3, mutualFriendWeight + sharedGroupWeight},
+		{2, mutualFriendWeight + sameCityWeight},
+		{4, 2 * sharedGroupWeight},
+	}
+	if len(ranked) != len(want) {
+		t.Fatalf("got %d suggestions, want %d", len(ranked), len(want))
+	}
+	for i, w := range want {
+		if ranked[i].ID != w.id || ranked[i].Score != w.score {
+			t.Errorf("ranked[%d] = {id %d, score %d}, want {id %d, score %d}", i, ranked[i].ID, ranked[i].Score, w.id, w.score)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package service
 
 import (
+	"errors"
 	"gorm.io/gorm"
 	"im-system/internal/model/db"
+	"strings"
+	"unicode/utf8"
 )
 
 type FriendGroupService struct {
@@ -15,11 +18,25 @@ func NewFriendShipService() *FriendGroupService {
 	}
 }
 
-// CreateFriendGroup 创建好友分组
+const maxFriendGroupNameLength = 32 // 好友分组名称最大长度
+
+// CreateFriendGroup 创建好友分组，新分组排在最后
 func (s *FriendGroupService) CreateFriendGroup(userID uint, groupName string) error {
+	groupName, err := s.checkGroupName(userID, 0, groupName)
+	if err != nil {
+		return err
+	}
+
+	var maxOrder int
+	if err := s.db.Model(&db.FriendGroup{}).Where("user_id = ?", userID).
+		Select("COALESCE(MAX(sort_order), 0)").Scan(&maxOrder).Error; err != nil {
+		return err
+	}
+
 	group := db.FriendGroup{
 		UserID:    userID,
 		GroupName: groupName,
+		SortOrder: maxOrder + 1,
 	}
 
 	return s.db.Create(&group).Error
@@ -28,8 +45,133 @@ func (s *FriendGroupService) CreateFriendGroup(userID uint, groupName string) er
 // GetFriendGroupsWithMembers 获取好友的所有分组
 func (s *FriendGroupService) GetFriendGroupsWithMembers(userId uint) ([]db.FriendGroup, error) {
 	resp := make([]db.FriendGroup, 0)
-	if err := s.db.Where("user_id = ?", userId).Find(&resp).Error; err != nil {
+	if err := s.db.Where("user_id = ?", userId).Order("sort_order, id").Find(&resp).Error; err != nil {
 		return resp, err
 	}
 	return resp, nil
 }
+
+// RenameFriendGroup 重命名好友分组，默认分组不能重命名
+func (s *FriendGroupService) RenameFriendGroup(userID, groupID uint, groupName string) error {
+	group, err := s.getUserGroup(userID, groupID)
+	if err != nil {
+		return err
+	}
+	if group.GroupName == DefaultGroupName {
+		return errors.New("默认分组不能重命名")
+	}
+	groupName, err = s.checkGroupName(userID, groupID, groupName)
+	if err != nil {
+		return err
+	}
+	return s.db.Model(&group).Update("group_name", groupName).Error
+}
+
+// ReorderFriendGroups 按传入的顺序重新排列好友分组，必须包含用户的所有分组
+func (s *FriendGroupService) ReorderFriendGroups(userID uint, groupIDs []uint) error {
+	var count int64
+	if err := s.db.Model(&db.FriendGroup{}).Where("user_id = ? AND id IN ?", userID, groupIDs).Count(&count).Error; err != nil {
+		return err
+	}
+	var total int64
+	if err := s.db.Model(&db.FriendGroup{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		return err
+	}
+	if int(count) != len(groupIDs) || count != total {
+		return errors.New("分组列表与当前分组不一致")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for i, groupID := range groupIDs {
+			if err := tx.Model(&db.FriendGroup{}).Where("id = ? AND user_id = ?", groupID, userID).
+				Update("sort_order", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteFriendGroup 删除好友分组，分组中的好友移动到默认分组，默认分组不能删除
+func (s *FriendGroupService) DeleteFriendGroup(userID, groupID uint) error {
+	group, err := s.getUserGroup(userID, groupID)
+	if err != nil {
+		return err
+	}
+	if group.GroupName == DefaultGroupName {
+		return errors.New("默认分组不能删除")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var defaultGroup db.FriendGroup
+		if err := tx.Where("user_id = ? AND group_name = ?", userID, DefaultGroupName).First(&defaultGroup).Error; err != nil {
+			return errors.New("默认分组不存在")
+		}
+		if err := tx.Model(&db.Friendship{}).Where("user_id = ? AND group_id = ?", userID, groupID).
+			Update("group_id", defaultGroup.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&group).Error
+	})
+}
+
+// MoveFriends 将多个好友批量移动到指定分组
+func (s *FriendGroupService) MoveFriends(userID, groupID uint, friendIDs []uint) error {
+	if len(friendIDs) == 0 {
+		return errors.New("好友列表不能为空")
+	}
+	if _, err := s.getUserGroup(userID, groupID); err != nil {
+		return err
+	}
+
+	// 所有用户都必须是自己的好友，重复的ID只计算一次
+	unique := make(map[uint]struct{}, len(friendIDs))
+	for _, friendID := range friendIDs {
+		unique[friendID] = struct{}{}
+	}
+	var count int64
+	if err := s.db.Model(&db.Friendship{}).
+		Where("user_id = ? AND friend_id IN ? AND status = ?", userID, friendIDs, "accepted").
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) != len(unique) {
+		return errors.New("部分用户不是您的好友")
+	}
+
+	return s.db.Model(&db.Friendship{}).
+		Where("user_id = ? AND friend_id IN ? AND status = ?", userID, friendIDs, "accepted").
+		Update("group_id", groupID).Error
+}
+
+// getUserGroup 查询用户自己的好友分组
+func (s *FriendGroupService) getUserGroup(userID, groupID uint) (db.FriendGroup, error) {
+	var group db.FriendGroup
+	if err := s.db.Where("id = ? AND user_id = ?", groupID, userID).First(&group).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return group, errors.New("分组不存在")
+		}
+		return group, err
+	}
+	return group, nil
+}
+
+// checkGroupName 校验分组名称，同一个用户下的分组名称不能重复，excludeID 为正在重命名的分组
+func (s *FriendGroupService) checkGroupName(userID, excludeID uint, groupName string) (string, error) {
+	groupName = strings.TrimSpace(groupName)
+	if groupName == "" {
+		return "", errors.New("分组名称不能为空")
+	}
+	if utf8.RuneCountInString(groupName) > maxFriendGroupNameLength {
+		return "", errors.New("分组名称过长")
+	}
+	var count int64
+	if err := s.db.Model(&db.FriendGroup{}).Where("user_id = ? AND group_name = ? AND id <> ?", userID, groupName, excludeID).
+		Count(&count).Error; err != nil {
+		return "", err
+	}
+	if count > 0 {
+		return "", errors.New("分组名称已存在")
+	}
+	return groupName, nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"errors"
+	"im-system/internal/model/db"
+
+	"gorm.io/gorm"
+)
+
+// FriendshipRepairReport 好友关系修复结果
+type FriendshipRepairReport struct {
+	Duplicates int `json:"duplicates"` // 删除的重复记录数
+	Restored   int `json:"restored"`   // 对方缺失或仍是待处理，补全为已接受的记录数
+	Accepted   int `json:"accepted"`   // 对方已接受但自己仍是待处理，改为已接受的记录数
+	Deleted    int `json:"deleted"`    // 对方已拉黑、删除或拒绝，改为已删除的记录数
+}
+
+// friendshipPair 一对用户之间的单向关系
+type friendshipPair struct {
+	UserID   uint
+	FriendID uint
+}
+
+// friendshipFix 修复一条单向关系，Friendship 的 ID 为 0 表示对方一方缺失，需要新建
+type friendshipFix struct {
+	Friendship db.Friendship
+	Status     string // 修复后的状态
+}
+
+// RepairFriendships 检查并修复不对称的好友关系，dryRun 为 true 时只统计不修改
+func RepairFriendships(dryRun bool) (FriendshipRepairReport, error) {
+	var report FriendshipRepairReport
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := removeDuplicateFriendships(tx, dryRun, &report); err != nil {
+			return err
+		}
+
+		var friendships []db.Friendship
+		if err := tx.Order("id").Find(&friendships).Error; err != nil {
+			return err
+		}
+		var apply func(friendshipFix) error
+		if !dryRun {
+			apply = func(fix friendshipFix) error {
+				return applyFriendshipFix(tx, fix)
+			}
+		}
+		return repairFriendshipRelations(friendships, &report, apply)
+	})
+	return report, err
+}
+
+// repairFriendshipRelations 找出不对称的好友关系并统计，apply 为 nil 时只统计不写入。
+// 无论是否写入都会更新内存中的关系，保证只统计和实际修复的结果一致
+func repairFriendshipRelations(friendships []db.Friendship, report *FriendshipRepairReport, apply func(friendshipFix) error) error {
+	relations := make(map[friendshipPair]db.Friendship, len(friendships))
+	for _, friendship := range friendships {
+		relations[friendshipPair{friendship.UserID, friendship.FriendID}] = friendship
+	}
+
+	for _, record := range friendships {
+		// 使用修复过程中更新后的状态，避免同一对关系被重复修复和统计
+		friendship := relations[friendshipPair{record.UserID, record.FriendID}]
+		reverse, exists := relations[friendshipPair{friendship.FriendID, friendship.UserID}]
+		var fix friendshipFix
+		switch friendship.Status {
+		case FriendshipAccepted:
+			if !exists || reverse.Status == FriendshipPending {
+				// 对方缺失或仍是待处理，说明接受请求时只写入了一半，补全对方一方为好友
+				if !exists {
+					reverse = db.Friendship{UserID: friendship.FriendID, FriendID: friendship.UserID}
+				}
+				report.Restored++
+				fix = friendshipFix{Friendship: reverse, Status: FriendshipAccepted}
+			} else if reverse.Status != FriendshipAccepted {
+				// 对方已拉黑、删除或拒绝，无法区分是删除好友还是接受请求只完成了一半，以对方的选择为准，自己一方的好友关系失效
+				report.Deleted++
+				fix = friendshipFix{Friendship: friendship, Status: FriendshipDeleted}
+			}
+		case FriendshipPending:
+			// 对方已经是好友，说明接受请求时只写入了一半
+			if exists && reverse.Status == FriendshipAccepted {
+				report.Accepted++
+				fix = friendshipFix{Friendship: friendship, Status: FriendshipAccepted}
+			}
+		}
+		if fix.Status == "" {
+			continue
+		}
+
+		if apply != nil {
+			if err := apply(fix); err != nil {
+				return err
+			}
+		}
+		fixed := fix.Friendship
+		fixed.Status = fix.Status
+		relations[friendshipPair{fixed.UserID, fixed.FriendID}] = fixed
+	}
+	return nil
+}
+
+// applyFriendshipFix 将修复写入数据库
+func applyFriendshipFix(tx *gorm.DB, fix friendshipFix) error {
+	if fix.Friendship.ID == 0 {
+		return createReverseFriendship(tx, fix.Friendship.UserID, fix.Friendship.FriendID)
+	}
+	return tx.Model(&fix.Friendship).Update("status", fix.Status).Error
+}
+
+// removeDuplicateFriendships 同一对用户同一方向有多条记录时只保留最新的一条
+func removeDuplicateFriendships(tx *gorm.DB, dryRun bool, report *FriendshipRepairReport) error {
+	var duplicates []struct {
+		UserID   uint
+		FriendID uint
+		KeepID   uint
+		Total    int
+	}
+	if err := tx.Model(&db.Friendship{}).
+		Select("user_id, friend_id, MAX(id) AS keep_id, COUNT(*) AS total").
+		Group("user_id, friend_id").
+		Having("COUNT(*) > 1").
+		Scan(&duplicates).Error; err != nil {
+		return err
+	}
+	for _, duplicate := range duplicates {
+		report.Duplicates += duplicate.Total - 1
+		if dryRun {
+			continue
+		}
+		if err := tx.Where("user_id = ? AND friend_id = ? AND id <> ?", duplicate.UserID, duplicate.FriendID, duplicate.KeepID).
+			Delete(&db.Friendship{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createReverseFriendship 补全缺失的对方一方好友关系，放入对方的默认分组，备注为好友的用户名
+func createReverseFriendship(tx *gorm.DB, userID, friendID uint) error {
+	var friend db.User
+	if err := tx.Select("id", "username").First(&friend, friendID).Error; err != nil {
+		return err
+	}
+	reverse := db.Friendship{
+		UserID:   userID,
+		FriendID: friendID,
+		Status:   FriendshipAccepted,
+		Remark:   friend.Username,
+	}
+	var defaultGroup db.FriendGroup
+	err := tx.Where("user_id = ? AND group_name = ?", userID, DefaultGroupName).First(&defaultGroup).Error
+	if err == nil {
+		reverse.GroupID = defaultGroup.ID
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return tx.Create(&reverse).Error
+}
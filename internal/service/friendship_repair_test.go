@@ -0,0 +1,88 @@
+package service
+
+import (
+	"im-system/internal/model/db"
+	"testing"
+)
+
+func TestRepairFriendshipRelationsDryRunMatchesRealRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		friendships []db.Friendship
+		want        FriendshipRepairReport
+	}{
+		{
+			name: "accepted and pending",
+			friendships: []db.Friendship{
+				{ID: 1, UserID: 1, FriendID: 2, Status: FriendshipAccepted},
+				{ID: 2, UserID: 2, FriendID: 1, Status: FriendshipPending},
+			},
+			want: FriendshipRepairReport{Restored: 1},
+		},
+		{
+			name: "pending before accepted",
+			friendships: []db.Friendship{
+				{ID: 1, UserID: 2, FriendID: 1, Status: FriendshipPending},
+				{ID: 2, UserID: 1, FriendID: 2, Status: FriendshipAccepted},
+			},
+			want: FriendshipRepairReport{Accepted: 1},
+		},
+		{
+			name: "reverse missing",
+			friendships: []db.Friendship{
+				{ID: 1, UserID: 1, FriendID: 2, Status: FriendshipAccepted},
+			},
+			want: FriendshipRepairReport{Restored: 1},
+		},
+		{
+			name: "reverse deleted, rejected or blocked",
+			friendships: []db.Friendship{
+				{ID: 1, UserID: 1, FriendID: 2, Status: FriendshipAccepted},
+				{ID: 2, UserID: 2, FriendID: 1, Status: FriendshipDeleted},
+				{ID: 3, UserID: 1, FriendID: 3, Status: FriendshipAccepted},
+				{ID: 4, UserID: 3, FriendID: 1, Status: FriendshipRejected},
+				{ID: 5, UserID: 1, FriendID: 4, Status: FriendshipAccepted},
+				{ID: 6, UserID: 4, FriendID: 1, Status: FriendshipBlocked},
+			},
+			want: FriendshipRepairReport{Deleted: 3},
+		},
+		{
+			name: "consistent",
+			friendships: []db.Friendship{
+				{ID: 1, UserID: 1, FriendID: 2, Status: FriendshipAccepted},
+				{ID: 2, UserID: 2, FriendID: 1, Status: FriendshipAccepted},
+				{ID: 3, UserID: 1, FriendID: 3, Status: FriendshipPending},
+				{ID: 4, UserID: 4, FriendID: 1, Status: FriendshipBlocked},
+			},
+			want: FriendshipRepairReport{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dryRun FriendshipRepairReport
+			if err := repairFriendshipRelations(tt.friendships, &dryRun, nil); err != nil {
+				t.Fatalf("dry run: %v", err)
+			}
+
+			var realRun FriendshipRepairReport
+			var fixes []friendshipFix
+			apply := func(fix friendshipFix) error {
+				fixes = append(fixes, fix)
+				return nil
+			}
+			if err := repairFriendshipRelations(tt.friendships, &realRun, apply); err != nil {
+				t.Fatalf("real run: %v", err)
+			}
+
+			if dryRun != realRun {
+				t.Errorf("dry run report %+v, real run report %+v", dryRun, realRun)
+			}
+			if realRun != tt.want {
+				t.Errorf("report = %+v, want %+v", realRun, tt.want)
+			}
+			if total := realRun.Restored + realRun.Accepted + realRun.Deleted; len(fixes) != total {
+				t.Errorf("applied %d fixes, report counts %d", len(fixes), total)
+			}
+		})
+	}
+}
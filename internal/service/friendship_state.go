@@ -0,0 +1,54 @@
+package service
+
+import (
+	"errors"
+	"im-system/internal/model/db"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 好友关系状态，每条记录表示 user_id 一方看到的关系，同一对用户每个方向只有一条记录
+const (
+	FriendshipPending  = "pending"  // 已发送好友请求，等待对方处理
+	FriendshipAccepted = "accepted" // 已是好友
+	FriendshipRejected = "rejected" // 好友请求被拒绝
+	FriendshipDeleted  = "deleted"  // 已删除好友，或好友请求已过期
+	FriendshipBlocked  = "blocked"  // 已拉黑对方
+)
+
+// friendshipTransitions 好友关系允许的状态切换，空状态表示记录还不存在
+var friendshipTransitions = map[string]map[string]bool{
+	"":                 {FriendshipPending: true, FriendshipAccepted: true, FriendshipBlocked: true},
+	FriendshipPending:  {FriendshipPending: true, FriendshipAccepted: true, FriendshipRejected: true, FriendshipDeleted: true, FriendshipBlocked: true},
+	FriendshipRejected: {FriendshipPending: true, FriendshipAccepted: true, FriendshipDeleted: true, FriendshipBlocked: true},
+	FriendshipDeleted:  {FriendshipPending: true, FriendshipAccepted: true, FriendshipBlocked: true},
+	FriendshipAccepted: {FriendshipDeleted: true, FriendshipBlocked: true},
+	FriendshipBlocked:  {FriendshipDeleted: true},
+}
+
+// CanTransitFriendship 判断好友关系是否可以从 from 切换到 to
+func CanTransitFriendship(from, to string) bool {
+	return friendshipTransitions[from][to]
+}
+
+// lockFriendship 锁定 userID 一方的好友关系记录，记录不存在时返回状态为空的新记录
+func lockFriendship(tx *gorm.DB, userID, friendID uint) (db.Friendship, error) {
+	var friendship db.Friendship
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND friend_id = ?", userID, friendID).
+		First(&friendship).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return db.Friendship{UserID: userID, FriendID: friendID}, nil
+	}
+	return friendship, err
+}
+
+// transitFriendship 将好友关系切换到新状态并保存，记录不存在时创建，调用前修改的其他字段会一起保存
+func transitFriendship(tx *gorm.DB, friendship *db.Friendship, to string) error {
+	if !CanTransitFriendship(friendship.Status, to) {
+		return errors.New("当前好友关系不允许该操作")
+	}
+	friendship.Status = to
+	return tx.Save(friendship).Error
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"errors"
+	"im-system/internal/config"
+	"im-system/internal/model/db"
+	"im-system/internal/model/vo"
+	"im-system/internal/module/hub"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PublishAnnouncement 发布群公告，并推送给所有在线的群成员
+func (s *GroupService) PublishAnnouncement(groupID, authorID uint, content string, pinned, requireAck bool) (vo.GroupAnnouncementVO, error) {
+	if content == "" {
+		return vo.GroupAnnouncementVO{}, errors.New("公告内容不能为空")
+	}
+
+	if _, err := s.authorize(s.db, groupID, authorID, ActionManageAnnouncements); err != nil {
+		return vo.GroupAnnouncementVO{}, err
+	}
+
+	var announcement db.GroupAnnouncement
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定群记录，保证同一个群的版本号递增
+		var group db.Group
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&group, groupID).Error; err != nil {
+			return errors.New("群组不存在")
+		}
+		var version int
+		if err := tx.Model(&db.GroupAnnouncement{}).Where("group_id = ?", groupID).
+			Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+			return err
+		}
+		// 同一时间只有一条置顶公告
+		if pinned {
+			if err := tx.Model(&db.GroupAnnouncement{}).Where("group_id = ? AND pinned = ?", groupID, true).
+				Update("pinned", false).Error; err != nil {
+				return err
+			}
+		}
+
+		announcement = db.GroupAnnouncement{
+			GroupID:    groupID,
+			AuthorID:   authorID,
+			Version:    version + 1,
+			Content:    content,
+			Pinned:     pinned,
+			RequireAck: requireAck,
+		}
+		if err := tx.Create(&announcement).Error; err != nil {
+			return err
+		}
+		if err := recordAudit(tx, auditEntry{
+			Action:  AuditPublishAnnouncement,
+			GroupID: groupID,
+			ActorID: authorID,
+			After: map[string]interface{}{
+				"announcement_id": announcement.ID,
+				"version":         announcement.Version,
+				"pinned":          pinned,
+				"require_ack":     requireAck,
+			},
+			Message: "发布群公告",
+		}); err != nil {
+			return err
+		}
+		return syncGroupAnnouncement(tx, groupID)
+	})
+	if err != nil {
+		return vo.GroupAnnouncementVO{}, err
+	}
+
+	announcementVO := s.toAnnouncementVO(announcement, false, 0)
+	s.pushToGroup(groupID, hub.EventGroupAnnouncement, announcementVO)
+	return announcementVO, nil
+}
+
+// GetAnnouncements 获取群公告历史，置顶公告排在最前
+func (s *GroupService) GetAnnouncements(groupID, userID uint) ([]vo.GroupAnnouncementVO, error) {
+	var member db.GroupMember
+	if err := s.db.Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error; err != nil {
+		return nil, errors.New("您不是该群组成员")
+	}
+
+	var announcements []db.GroupAnnouncement
+	if err := s.db.Where("group_id = ?", groupID).Order("pinned desc, version desc").Find(&announcements).Error; err != nil {
+		return nil, err
+	}
+
+	resp := make([]vo.GroupAnnouncementVO, 0, len(announcements))
+	for _, announcement := range announcements {
+		var ackCount int64
+		if err := s.db.Model(&db.GroupAnnouncementAck{}).Where("announcement_id = ?", announcement.ID).Count(&ackCount).Error; err != nil {
+			return nil, err
+		}
+		var acked int64
+		if err := s.db.Model(&db.GroupAnnouncementAck{}).Where("announcement_id = ? AND user_id = ?", announcement.ID, userID).Count(&acked).Error; err != nil {
+			return nil, err
+		}
+		resp = append(resp, s.toAnnouncementVO(announcement, acked > 0, int(ackCount)))
+	}
+	return resp, nil
+}
+
+// PinAnnouncement 置顶或取消置顶群公告
+func (s *GroupService) PinAnnouncement(announcementID, userID uint, pinned bool) error {
+	announcement, err := s.getManagedAnnouncement(announcementID, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if pinned {
+			if err := tx.Model(&db.GroupAnnouncement{}).Where("group_id = ? AND pinned = ?", announcement.GroupID, true).
+				Update("pinned", false).Error; err != nil {
+				return err
+			}
+		}
+		before := announcement.Pinned
+		if err := tx.Model(&announcement).Update("pinned", pinned).Error; err != nil {
+			return err
+		}
+		if err := recordAudit(tx, auditEntry{
+			Action:  AuditPinAnnouncement,
+			GroupID: announcement.GroupID,
+			ActorID: userID,
+			Before:  map[string]interface{}{"announcement_id": announcement.ID, "pinned": before},
+			After:   map[string]interface{}{"announcement_id": announcement.ID, "pinned": pinned},
+			Message: "置顶或取消置顶群公告",
+		}); err != nil {
+			return err
+		}
+		return syncGroupAnnouncement(tx, announcement.GroupID)
+	})
+}
+
+// DeleteAnnouncement 删除群公告，删除后群资料中的公告回退到上一条
+func (s *GroupService) DeleteAnnouncement(announcementID, userID uint) error {
+	announcement, err := s.getManagedAnnouncement(announcementID, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("announcement_id = ?", announcement.ID).Delete(&db.GroupAnnouncementAck{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&announcement).Error; err != nil {
+			return err
+		}
+		if err := recordAudit(tx, auditEntry{
+			Action:  AuditDeleteAnnouncement,
+			GroupID: announcement.GroupID,
+			ActorID: userID,
+			Before: map[string]interface{}{
+				"announcement_id": announcement.ID,
+				"version":         announcement.Version,
+				"content":         announcement.Content,
+			},
+			Message: "删除群公告",
+		}); err != nil {
+			return err
+		}
+		return syncGroupAnnouncement(tx, announcement.GroupID)
+	})
+}
+
+// AcknowledgeAnnouncement 确认已读群公告，重复确认不会报错
+func (s *GroupService) AcknowledgeAnnouncement(announcementID, userID uint) error {
+	var announcement db.GroupAnnouncement
+	if err := s.db.First(&announcement, announcementID).Error; err != nil {
+		return errors.New("公告不存在")
+	}
+	if !announcement.RequireAck {
+		return errors.New("该公告不需要确认")
+	}
+	var member db.GroupMember
+	if err := s.db.Where("group_id = ? AND user_id = ?", announcement.GroupID, userID).First(&member).Error; err != nil {
+		return errors.New("您不是该群组成员")
+	}
+
+	ack := db.GroupAnnouncementAck{AnnouncementID: announcementID, UserID: userID}
+	return s.db.Where("announcement_id = ? AND user_id = ?", announcementID, userID).FirstOrCreate(&ack).Error
+}
+
+// GetAnnouncementAcks 获取群公告的确认情况
+func (s *GroupService) GetAnnouncementAcks(announcementID, userID uint) (vo.AnnouncementAckVO, error) {
+	announcement, err := s.getManagedAnnouncement(announcementID, userID)
+	if err != nil {
+		return vo.AnnouncementAckVO{}, err
+	}
+
+	var acks []db.GroupAnnouncementAck
+	if err := s.db.Where("announcement_id = ?", announcement.ID).Find(&acks).Error; err != nil {
+		return vo.AnnouncementAckVO{}, err
+	}
+	ackedUsers := make(map[uint]bool, len(acks))
+	for _, ack := range acks {
+		ackedUsers[ack.UserID] = true
+	}
+
+	var members []db.GroupMember
+	if err := s.db.Where("group_id = ?", announcement.GroupID).Find(&members).Error; err != nil {
+		return vo.AnnouncementAckVO{}, err
+	}
+	resp := vo.AnnouncementAckVO{
+		Acked:   make([]vo.PreviewMember, 0),
+		Pending: make([]vo.PreviewMember, 0),
+	}
+	for _, member := range members {
+		var user db.User
+		if err := s.db.First(&user, member.UserID).Error; err != nil {
+			return vo.AnnouncementAckVO{}, err
+		}
+		preview := vo.PreviewMember{
+			ID:     member.UserID,
+			Name:   member.Nickname,
+			Avatar: user.AvatarURL,
+			Role:   member.Role,
+		}
+		if ackedUsers[member.UserID] {
+			resp.Acked = append(resp.Acked, preview)
+		} else {
+			resp.Pending = append(resp.Pending, preview)
+		}
+	}
+	return resp, nil
+}
+
+// getManagedAnnouncement 查询公告，并校验用户有权限管理该群的公告
+func (s *GroupService) getManagedAnnouncement(announcementID, userID uint) (db.GroupAnnouncement, error) {
+	var announcement db.GroupAnnouncement
+	if err := s.db.First(&announcement, announcementID).Error; err != nil {
+		return announcement, errors.New("公告不存在")
+	}
+	if _, err := s.authorize(s.db, announcement.GroupID, userID, ActionManageAnnouncements); err != nil {
+		return announcement, err
+	}
+	return announcement, nil
+}
+
+// toAnnouncementVO 转换为群公告视图对象
+func (s *GroupService) toAnnouncementVO(announcement db.GroupAnnouncement, acked bool, ackCount int) vo.GroupAnnouncementVO {
+	announcementVO := vo.GroupAnnouncementVO{
+		ID:         announcement.ID,
+		GroupID:    announcement.GroupID,
+		AuthorID:   announcement.AuthorID,
+		Version:    announcement.Version,
+		Content:    announcement.Content,
+		Pinned:     announcement.Pinned,
+		RequireAck: announcement.RequireAck,
+		Acked:      acked,
+		AckCount:   ackCount,
+		CreatedAt:  announcement.CreatedAt,
+	}
+	var author db.User
+	if err := s.db.First(&author, announcement.AuthorID).Error; err == nil {
+		announcementVO.AuthorName = author.Username
+		announcementVO.AuthorAvatar = author.AvatarURL
+	}
+	return announcementVO
+}
+
+// pushToGroup 向群内所有在线成员推送事件
+func (s *GroupService) pushToGroup(groupID uint, eventType string, data interface{}) {
+	var memberIDs []uint
+	if err := s.db.Model(&db.GroupMember{}).Where("group_id = ?", groupID).Pluck("user_id", &memberIDs).Error; err != nil {
+		config.Logger.Error(err)
+		return
+	}
+	hub.Default.PushEvent(memberIDs, eventType, data)
+}
+
+// syncGroupAnnouncement 将群资料中的公告同步为置顶公告，没有置顶时使用最新一条，没有公告时清空
+func syncGroupAnnouncement(tx *gorm.DB, groupID uint) error {
+	var current db.GroupAnnouncement
+	content := ""
+	err := tx.Where("group_id = ?", groupID).Order("pinned desc, version desc").First(&current).Error
+	if err == nil {
+		content = current.Content
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return tx.Model(&db.Group{}).Where("id = ?", groupID).Update("announcement", content).Error
+}
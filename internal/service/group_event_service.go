@@ -0,0 +1,167 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"im-system/internal/config"
+	"im-system/internal/model/db"
+	"im-system/internal/model/vo"
+	"im-system/internal/module/hub"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 群成员变动类型
+const (
+	MemberJoined  = "join"   // 入群
+	MemberRemoved = "remove" // 被移出群聊
+	MemberQuit    = "quit"   // 退出群聊
+)
+
+// 群组相关的通知类型
+const (
+	NotificationGroupInvite  = "group_invite"  // 被邀请入群
+	NotificationGroupRemoved = "group_removed" // 被移出群聊
+)
+
+// groupSystemMessage 群聊时间线中的系统消息，字段与 WebSocket 聊天消息保持一致
+type groupSystemMessage struct {
+	SenderId    int    `json:"senderId"`
+	GroupID     int    `json:"groupId"`
+	SenderName  string `json:"senderName"`
+	Avatar      string `json:"avatar"`
+	Content     string `json:"content"`
+	MessageType string `json:"messageType"` // 固定为 "system"
+	CreatedAt   string `json:"createdAt"`
+}
+
+// membershipChange 一次群成员变动，事务提交后推送给相关用户
+type membershipChange struct {
+	GroupID       uint
+	Action        string
+	OperatorID    uint
+	UserIDs       []uint
+	Operator      db.User           // 操作者，只包含通知中展示的公开资料
+	SystemMessage []byte            // 群聊时间线中的系统消息
+	Notifications []db.Notification // 发给受影响用户的通知
+}
+
+// recordMembershipChange 在事务中写入群系统消息，并给受影响的用户创建通知
+func (s *GroupService) recordMembershipChange(tx *gorm.DB, group db.Group, action string, operatorID uint, userIDs []uint) (membershipChange, error) {
+	change := membershipChange{GroupID: group.ID, Action: action, OperatorID: operatorID, UserIDs: userIDs}
+	if len(userIDs) == 0 {
+		return change, nil
+	}
+
+	// 操作者会随通知推送给受影响的用户，只读取公开资料字段
+	var operator db.User
+	if err := tx.Select(notificationSenderColumns).First(&operator, operatorID).Error; err != nil {
+		return change, err
+	}
+	change.Operator = operator
+	var users []db.User
+	if err := tx.Select("id", "username").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return change, err
+	}
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, user.Username)
+	}
+	joined := strings.Join(names, "、")
+
+	var text, notificationType, notificationText string
+	switch action {
+	case MemberJoined:
+		if len(userIDs) == 1 && userIDs[0] == operatorID {
+			text = fmt.Sprintf("%s 通过邀请链接加入了群聊", joined)
+		} else {
+			text = fmt.Sprintf("%s 邀请 %s 加入了群聊", operator.Username, joined)
+			notificationType = NotificationGroupInvite
+			notificationText = fmt.Sprintf("%s 邀请你加入了群聊「%s」", operator.Username, group.Name)
+		}
+	case MemberRemoved:
+		text = fmt.Sprintf("%s 将 %s 移出了群聊", operator.Username, joined)
+		notificationType = NotificationGroupRemoved
+		notificationText = fmt.Sprintf("你已被 %s 移出群聊「%s」", operator.Username, group.Name)
+	case MemberQuit:
+		text = fmt.Sprintf("%s 退出了群聊", joined)
+	}
+
+	// 系统消息写入群聊时间线，发送者记为操作者
+	now := time.Now()
+	payload, err := json.Marshal(groupSystemMessage{
+		SenderId:    int(operatorID),
+		GroupID:     int(group.ID),
+		SenderName:  operator.Username,
+		Avatar:      operator.AvatarURL,
+		Content:     text,
+		MessageType: "system",
+		CreatedAt:   now.Format(time.RFC3339),
+	})
+	if err != nil {
+		return change, err
+	}
+	message := db.Message{
+		SenderID:        operatorID,
+		ReceiverGroupID: group.ID,
+		Content:         string(payload),
+		MessageType:     "system",
+	}
+	if err := tx.Create(&message).Error; err != nil {
+		return change, err
+	}
+	change.SystemMessage = payload
+
+	if notificationType == "" {
+		return change, nil
+	}
+	for _, userID := range userIDs {
+		notification := db.Notification{
+			SenderID:   operatorID,
+			ReceiverID: userID,
+			Type:       notificationType,
+			Content:    notificationText,
+			Status:     "accepted",
+			GroupID:    group.ID,
+		}
+		if err := tx.Create(&notification).Error; err != nil {
+			return change, err
+		}
+		change.Notifications = append(change.Notifications, notification)
+	}
+	return change, nil
+}
+
+// publishMembershipChange 推送群成员变动：系统消息和变动事件发给群成员，通知发给受影响的用户
+func (s *GroupService) publishMembershipChange(change membershipChange) {
+	if len(change.UserIDs) == 0 {
+		return
+	}
+
+	var memberIDs []uint
+	if err := s.db.Model(&db.GroupMember{}).Where("group_id = ?", change.GroupID).Pluck("user_id", &memberIDs).Error; err != nil {
+		config.Logger.Error(err)
+		return
+	}
+	if change.SystemMessage != nil {
+		hub.Default.SendToUsers(memberIDs, change.SystemMessage)
+	}
+
+	// 被移出或退出的用户已经不在群中，也需要收到变动事件以更新群列表
+	receivers := memberIDs
+	if change.Action != MemberJoined {
+		receivers = append(receivers, change.UserIDs...)
+	}
+	hub.Default.PushEvent(receivers, hub.EventGroupMemberChanged, vo.GroupMemberChangedVO{
+		GroupID:    change.GroupID,
+		Action:     change.Action,
+		OperatorID: change.OperatorID,
+		UserIDs:    change.UserIDs,
+	})
+
+	for _, notification := range change.Notifications {
+		pushNotificationFrom(s.db, notification, change.Operator)
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"im-system/internal/model/db"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// inviteCodeBytes 邀请码随机字节数，编码后长度为其两倍
+const inviteCodeBytes = 6
+
+// CreateInviteLink 创建群邀请链接
+func (s *GroupService) CreateInviteLink(userId, groupId uint, expiresIn time.Duration, maxUses int) (db.GroupInviteLink, error) {
+	if maxUses < 0 {
+		return db.GroupInviteLink{}, errors.New("最大使用次数不能为负数")
+	}
+	if expiresIn < 0 {
+		return db.GroupInviteLink{}, errors.New("有效期不能为负数")
+	}
+	// 查询群聊信息
+	var group db.Group
+	if err := s.db.First(&group, groupId).Error; err != nil {
+		return db.GroupInviteLink{}, errors.New("群组不存在")
+	}
+	// 判断用户是否有权限创建邀请链接
+	if _, err := s.authorize(s.db, groupId, userId, ActionManageInviteLinks); err != nil {
+		return db.GroupInviteLink{}, err
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return db.GroupInviteLink{}, err
+	}
+	link := db.GroupInviteLink{
+		GroupID:   groupId,
+		CreatorID: userId,
+		Code:      code,
+		MaxUses:   maxUses,
+	}
+	if expiresIn > 0 {
+		expiresAt := time.Now().Add(expiresIn)
+		link.ExpiresAt = &expiresAt
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&link).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditEntry{
+			Action:  AuditCreateInviteLink,
+			GroupID: groupId,
+			ActorID: userId,
+			After: map[string]interface{}{
+				"link_id":    link.ID,
+				"max_uses":   link.MaxUses,
+				"expires_at": link.ExpiresAt,
+			},
+			Message: "创建群邀请链接",
+		})
+	})
+	if err != nil {
+		return db.GroupInviteLink{}, err
+	}
+	return link, nil
+}
+
+// GetInviteLinks 获取群聊的所有邀请链接
+func (s *GroupService) GetInviteLinks(userId, groupId uint) ([]db.GroupInviteLink, error) {
+	if _, err := s.authorize(s.db, groupId, userId, ActionManageInviteLinks); err != nil {
+		return nil, err
+	}
+
+	links := make([]db.GroupInviteLink, 0)
+	if err := s.db.Where("group_id = ?", groupId).Order("created_at desc").Find(&links).Error; err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// RevokeInviteLink 撤销群邀请链接
+func (s *GroupService) RevokeInviteLink(userId, linkId uint) error {
+	var link db.GroupInviteLink
+	if err := s.db.First(&link, linkId).Error; err != nil {
+		return errors.New("邀请链接不存在")
+	}
+	if _, err := s.authorize(s.db, link.GroupID, userId, ActionManageInviteLinks); err != nil {
+		return err
+	}
+	if link.Revoked {
+		return nil
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&link).Update("revoked", true).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditEntry{
+			Action:  AuditRevokeInviteLink,
+			GroupID: link.GroupID,
+			ActorID: userId,
+			Before:  map[string]interface{}{"link_id": link.ID, "revoked": false},
+			After:   map[string]interface{}{"link_id": link.ID, "revoked": true},
+			Message: "撤销群邀请链接",
+		})
+	})
+}
+
+// JoinGroupByLink 通过邀请码加入群聊，并记录使用的邀请链接
+func (s *GroupService) JoinGroupByLink(userId uint, code string) (db.Group, error) {
+	var group db.Group
+	var change membershipChange
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var link db.GroupInviteLink
+		if err := tx.Where("code = ?", code).First(&link).Error; err != nil {
+			return errors.New("邀请链接不存在")
+		}
+		if link.Revoked {
+			return errors.New("邀请链接已被撤销")
+		}
+		if link.ExpiresAt != nil && time.Now().After(*link.ExpiresAt) {
+			return errors.New("邀请链接已过期")
+		}
+		if err := tx.First(&group, link.GroupID).Error; err != nil {
+			return errors.New("群组不存在")
+		}
+
+		if err := s.joinGroup(tx, group, userId); err != nil {
+			if errors.Is(err, errAlreadyMember) {
+				return errors.New("您已经是该群组成员")
+			}
+			return err
+		}
+
+		// 使用次数加一，使用条件更新防止并发超出上限
+		query := tx.Model(&db.GroupInviteLink{}).Where("id = ?", link.ID)
+		if link.MaxUses > 0 {
+			query = query.Where("used_count < max_uses")
+		}
+		result := query.Update("used_count", gorm.Expr("used_count + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("邀请链接使用次数已达上限")
+		}
+
+		// 记录入群来源
+		usage := db.GroupInviteLinkUsage{
+			LinkID:  link.ID,
+			GroupID: link.GroupID,
+			UserID:  userId,
+		}
+		if err := tx.Create(&usage).Error; err != nil {
+			return err
+		}
+		if err := recordAudit(tx, auditEntry{
+			Action:   AuditJoinByLink,
+			GroupID:  link.GroupID,
+			ActorID:  userId,
+			TargetID: userId,
+			After:    map[string]interface{}{"link_id": link.ID, "creator_id": link.CreatorID, "role": Member},
+			Message:  "通过邀请链接入群",
+		}); err != nil {
+			return err
+		}
+
+		var err error
+		change, err = s.recordMembershipChange(tx, group, MemberJoined, userId, []uint{userId})
+		return err
+	})
+	if err != nil {
+		return db.Group{}, err
+	}
+	s.invalidateGroupStats(group.ID)
+	s.publishMembershipChange(change)
+	return group, nil
+}
+
+// generateInviteCode 生成随机邀请码
+func generateInviteCode() (string, error) {
+	buf := make([]byte, inviteCodeBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"im-system/internal/model/db"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxGroupNicknameLength = 32 // 群昵称最大长度
+	maxGroupTitleLength    = 16 // 群称号最大长度
+)
+
+// SetMemberNickname 修改自己在群中的昵称，昵称为空时恢复为用户名
+func (s *GroupService) SetMemberNickname(groupID, userID uint, nickname string) error {
+	if utf8.RuneCountInString(nickname) > maxGroupNicknameLength {
+		return errors.New("群昵称过长")
+	}
+
+	member, err := s.authorize(s.db, groupID, userID, ActionSetNickname)
+	if err != nil {
+		return err
+	}
+
+	if nickname == "" {
+		var user db.User
+		if err := s.db.First(&user, userID).Error; err != nil {
+			return err
+		}
+		nickname = user.Username
+	}
+
+	return s.db.Model(&member).Update("nickname", nickname).Error
+}
+
+// SetMemberTitle 授予群成员称号，群主可以授予任何人，管理员只能授予普通成员和自己
+func (s *GroupService) SetMemberTitle(groupID, operatorID, memberID uint, title string) error {
+	if utf8.RuneCountInString(title) > maxGroupTitleLength {
+		return errors.New("群称号过长")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		_, member, err := s.authorizeOn(tx, groupID, operatorID, memberID, ActionGrantTitle)
+		if err != nil {
+			return err
+		}
+		if member.Title == title {
+			return nil
+		}
+
+		if err := tx.Model(&member).Update("title", title).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditEntry{
+			Action:   AuditGrantTitle,
+			GroupID:  groupID,
+			ActorID:  operatorID,
+			TargetID: memberID,
+			Before:   map[string]string{"title": member.Title},
+			After:    map[string]string{"title": title},
+			Message:  "修改成员的群称号",
+		})
+	})
+}
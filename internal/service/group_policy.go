@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"im-system/internal/model/db"
+
+	"gorm.io/gorm"
+)
+
+// GroupAction 群组操作
+type GroupAction string
+
+const (
+	ActionInviteMember        GroupAction = "invite_member"        // 邀请成员
+	ActionRemoveMember        GroupAction = "remove_member"        // 移除成员
+	ActionChangeRole          GroupAction = "change_role"          // 修改成员角色
+	ActionUpdateGroup         GroupAction = "update_group"         // 修改群资料
+	ActionManageInviteLinks   GroupAction = "manage_invite_links"  // 管理邀请链接
+	ActionManageAnnouncements GroupAction = "manage_announcements" // 管理群公告
+	ActionGrantTitle          GroupAction = "grant_title"          // 授予称号
+	ActionSetNickname         GroupAction = "set_nickname"         // 修改自己的群昵称
+	ActionQuitGroup           GroupAction = "quit_group"           // 退出群聊
+	ActionViewAudit           GroupAction = "view_audit"           // 查看审计日志
+)
+
+// groupCapabilities 角色 × 操作 的能力矩阵
+var groupCapabilities = map[string]map[GroupAction]bool{
+	Owner: {
+		ActionInviteMember:        true,
+		ActionRemoveMember:        true,
+		ActionChangeRole:          true,
+		ActionUpdateGroup:         true,
+		ActionManageInviteLinks:   true,
+		ActionManageAnnouncements: true,
+		ActionGrantTitle:          true,
+		ActionSetNickname:         true,
+		ActionViewAudit:           true,
+	},
+	Admin: {
+		ActionInviteMember:        true,
+		ActionRemoveMember:        true,
+		ActionUpdateGroup:         true,
+		ActionManageInviteLinks:   true,
+		ActionManageAnnouncements: true,
+		ActionGrantTitle:          true,
+		ActionSetNickname:         true,
+		ActionQuitGroup:           true,
+		ActionViewAudit:           true,
+	},
+	Member: {
+		ActionSetNickname: true,
+		ActionQuitGroup:   true,
+	},
+}
+
+// groupTargetRoles 针对其他成员的操作，操作者角色可以作用的目标角色
+var groupTargetRoles = map[string]map[GroupAction]map[string]bool{
+	Owner: {
+		ActionRemoveMember: {Admin: true, Member: true},
+		ActionChangeRole:   {Admin: true, Member: true},
+		ActionGrantTitle:   {Owner: true, Admin: true, Member: true},
+	},
+	Admin: {
+		ActionRemoveMember: {Member: true},
+		ActionGrantTitle:   {Member: true},
+	},
+}
+
+// groupAssignableRoles 操作者角色可以授予的角色，群主只能通过转让产生
+var groupAssignableRoles = map[string]map[string]bool{
+	Owner: {Admin: true, Member: true},
+}
+
+// CanPerform 判断角色是否可以执行操作
+func CanPerform(role string, action GroupAction) bool {
+	return groupCapabilities[role][action]
+}
+
+// CanActOn 判断角色是否可以对目标角色执行操作，对自己授予称号视为允许
+func CanActOn(role string, action GroupAction, targetRole string, self bool) bool {
+	if !CanPerform(role, action) {
+		return false
+	}
+	if self && action == ActionGrantTitle {
+		return true
+	}
+	if self {
+		return false
+	}
+	return groupTargetRoles[role][action][targetRole]
+}
+
+// CanAssignRole 判断角色是否可以授予新角色
+func CanAssignRole(role string, newRole string) bool {
+	return groupAssignableRoles[role][newRole]
+}
+
+// authorize 校验用户是群成员且有权限执行操作，返回操作者的成员信息
+func (s *GroupService) authorize(tx *gorm.DB, groupID, userID uint, action GroupAction) (db.GroupMember, error) {
+	var actor db.GroupMember
+	if err := tx.Where("group_id = ? AND user_id = ?", groupID, userID).First(&actor).Error; err != nil {
+		return actor, &ForbiddenError{Message: "您不是该群组成员"}
+	}
+	if !CanPerform(actor.Role, action) {
+		return actor, &ForbiddenError{Message: "您没有权限执行该操作"}
+	}
+	return actor, nil
+}
+
+// authorizeOn 校验用户有权限对群内另一个成员执行操作，返回操作者和目标的成员信息
+func (s *GroupService) authorizeOn(tx *gorm.DB, groupID, userID, targetID uint, action GroupAction) (db.GroupMember, db.GroupMember, error) {
+	var target db.GroupMember
+	actor, err := s.authorize(tx, groupID, userID, action)
+	if err != nil {
+		return actor, target, err
+	}
+	if err := tx.Where("group_id = ? AND user_id = ?", groupID, targetID).First(&target).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return actor, target, errors.New("该用户不是群组成员")
+		}
+		return actor, target, err
+	}
+	if !CanActOn(actor.Role, action, target.Role, actor.UserID == target.UserID) {
+		return actor, target, &ForbiddenError{Message: "您没有权限对该成员执行该操作"}
+	}
+	return actor, target, nil
+}
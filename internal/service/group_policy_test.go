@@ -0,0 +1,136 @@
+package service
+
+import "testing"
+
+var (
+	testGroupRoles   = []string{Owner, Admin, Member}
+	testGroupActions = []GroupAction{
+		ActionInviteMember,
+		ActionRemoveMember,
+		ActionChangeRole,
+		ActionUpdateGroup,
+		ActionManageInviteLinks,
+		ActionManageAnnouncements,
+		ActionGrantTitle,
+		ActionSetNickname,
+		ActionQuitGroup,
+		ActionViewAudit,
+	}
+)
+
+func TestCanPerform(t *testing.T) {
+	tests := []struct {
+		role    string
+		allowed []GroupAction
+	}{
+		{Owner, []GroupAction{
+			ActionInviteMember, ActionRemoveMember, ActionChangeRole, ActionUpdateGroup,
+			ActionManageInviteLinks, ActionManageAnnouncements, ActionGrantTitle,
+			ActionSetNickname, ActionViewAudit,
+		}},
+		{Admin, []GroupAction{
+			ActionInviteMember, ActionRemoveMember, ActionUpdateGroup,
+			ActionManageInviteLinks, ActionManageAnnouncements, ActionGrantTitle,
+			ActionSetNickname, ActionQuitGroup, ActionViewAudit,
+		}},
+		{Member, []GroupAction{ActionSetNickname, ActionQuitGroup}},
+		{"", nil},
+		{"unknown", nil},
+	}
+	for _, tt := range tests {
+		allowed := make(map[GroupAction]bool, len(tt.allowed))
+		for _, action := range tt.allowed {
+			allowed[action] = true
+		}
+		for _, action := range testGroupActions {
+			if got := CanPerform(tt.role, action); got != allowed[action] {
+				t.Errorf("CanPerform(%q, %q) = %v, want %v", tt.role, action, got, allowed[action])
+			}
+		}
+	}
+}
+
+func TestCanActOn(t *testing.T) {
+	type key struct {
+		role   string
+		action GroupAction
+		target string
+		self   bool
+	}
+	// 允许的组合，其余组合全部拒绝
+	allowed := map[key]bool{
+		{Owner, ActionRemoveMember, Admin, false}:  true,
+		{Owner, ActionRemoveMember, Member, false}: true,
+		{Owner, ActionChangeRole, Admin, false}:    true,
+		{Owner, ActionChangeRole, Member, false}:   true,
+		{Owner, ActionGrantTitle, Owner, false}:    true,
+		{Owner, ActionGrantTitle, Admin, false}:    true,
+		{Owner, ActionGrantTitle, Member, false}:   true,
+		{Admin, ActionRemoveMember, Member, false}: true,
+		{Admin, ActionGrantTitle, Member, false}:   true,
+		// 给自己授予称号
+		{Owner, ActionGrantTitle, Owner, true}:  true,
+		{Owner, ActionGrantTitle, Admin, true}:  true,
+		{Owner, ActionGrantTitle, Member, true}: true,
+		{Admin, ActionGrantTitle, Owner, true}:  true,
+		{Admin, ActionGrantTitle, Admin, true}:  true,
+		{Admin, ActionGrantTitle, Member, true}: true,
+	}
+	for _, role := range testGroupRoles {
+		for _, action := range testGroupActions {
+			for _, target := range testGroupRoles {
+				for _, self := range []bool{false, true} {
+					want := allowed[key{role, action, target, self}]
+					if got := CanActOn(role, action, target, self); got != want {
+						t.Errorf("CanActOn(%q, %q, %q, self=%v) = %v, want %v", role, action, target, self, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCanAssignRole(t *testing.T) {
+	allowed := map[[2]string]bool{
+		{Owner, Admin}:  true,
+		{Owner, Member}: true,
+	}
+	for _, role := range testGroupRoles {
+		for _, newRole := range testGroupRoles {
+			want := allowed[[2]string{role, newRole}]
+			if got := CanAssignRole(role, newRole); got != want {
+				t.Errorf("CanAssignRole(%q, %q) = %v, want %v", role, newRole, got, want)
+			}
+		}
+	}
+}
+
+func TestAdminCannotEscalate(t *testing.T) {
+	for _, newRole := range []string{Owner, Admin} {
+		if CanAssignRole(Admin, newRole) {
+			t.Errorf("admin can assign %q", newRole)
+		}
+	}
+	if CanActOn(Admin, ActionChangeRole, Admin, true) {
+		t.Error("admin can change own role")
+	}
+	if CanActOn(Member, ActionChangeRole, Member, true) {
+		t.Error("member can change own role")
+	}
+}
+
+func TestOwnerCannotBeRemovedOrDemoted(t *testing.T) {
+	for _, role := range testGroupRoles {
+		for _, self := range []bool{false, true} {
+			if CanActOn(role, ActionRemoveMember, Owner, self) {
+				t.Errorf("%q can remove the owner (self=%v)", role, self)
+			}
+			if CanActOn(role, ActionChangeRole, Owner, self) {
+				t.Errorf("%q can change the owner's role (self=%v)", role, self)
+			}
+		}
+		if CanAssignRole(role, Owner) {
+			t.Errorf("%q can assign the owner role", role)
+		}
+	}
+}
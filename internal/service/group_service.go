@@ -2,10 +2,14 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"im-system/internal/config"
 	"im-system/internal/model/db"
 	"im-system/internal/model/vo"
+	"im-system/internal/module/usercache"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GroupService 群组服务
@@ -36,10 +40,12 @@ const (
 	defaultUserAvatar = "https://encrypted-tbn0.gstatic.com/images?q=tbn:ANd9GcQ3w2fqb71MsCj97IKLAUXoI6BS4IfeCeEoq_XGS3X2CErGlYyP4xxX4eQ&s"
 	GroupTypePublic   = "public"
 	GroupTypePrivate  = "private"
+	// previewMemberLimit 群列表中预览成员的数量
+	previewMemberLimit = 10
 )
 
 // CreateGroup 创建群组
-func (s *GroupService) CreateGroup(group db.Group, userInfo db.User) error {
+func (s *GroupService) CreateGroup(group db.Group, userInfo usercache.CachedUser) error {
 	// 创建群组记录
 	if err := s.db.Create(&group).Error; err != nil {
 		return err
@@ -163,6 +169,11 @@ func (s *GroupService) GetMyAllGroups(userId uint) (vo.GroupChatList, error) {
 	if err := s.db.Where("user_id =?", userId).Find(&groupMembers).Error; err != nil {
 		return vo.GroupChatList{}, err
 	}
+	// 查询当前用户信息，用于统计本地人数
+	var viewer db.User
+	if err := s.db.First(&viewer, userId).Error; err != nil {
+		return vo.GroupChatList{}, err
+	}
 	// 构建响应数据
 	var groupChatList vo.GroupChatList
 	var createGroups []vo.GroupChatDetail
@@ -174,16 +185,20 @@ func (s *GroupService) GetMyAllGroups(userId uint) (vo.GroupChatList, error) {
 		if err := s.db.First(&group, groupMember.GroupID).Error; err != nil {
 			return vo.GroupChatList{}, err
 		}
-		// todo: 查询群的统计信息
-		// 查询群成员信息
+		// 统计群成员数量，只加载部分成员用于预览
+		var memberCount int64
+		if err := s.db.Model(&db.GroupMember{}).Where("group_id =?", groupMember.GroupID).Count(&memberCount).Error; err != nil {
+			return vo.GroupChatList{}, err
+		}
 		var members []db.GroupMember
-		if err := s.db.Where("group_id =?", groupMember.GroupID).Find(&members).Error; err != nil {
+		if err := s.db.Where("group_id =?", groupMember.GroupID).Order("id").Limit(previewMemberLimit).Find(&members).Error; err != nil {
+			return vo.GroupChatList{}, err
+		}
+		// 查询群的统计信息
+		groupStatus, err := s.getGroupStats(group.ID, viewer.City)
+		if err != nil {
 			return vo.GroupChatList{}, err
 		}
-		var groupStatus vo.GroupStats
-		groupStatus.Active = len(members)
-		groupStatus.Male = 1
-		groupStatus.Local = 0
 		var previewMembers []vo.PreviewMember
 		for _, member := range members {
 			// 查询用户信息
@@ -201,12 +216,12 @@ func (s *GroupService) GetMyAllGroups(userId uint) (vo.GroupChatList, error) {
 		// 判断是否是创建者，管理员，普通成员
 		if groupMember.Role == Owner {
 			createGroups = append(createGroups, vo.GroupChatDetail{
-				ID:          group.ID,
-				Name:        group.Name,
-				Avatar:      group.GroupAvatar,
-				MemberCount: len(members),
-				// todo: 添加群聊统计信息
-				Category:       "游戏交友",
+				ID:             group.ID,
+				Name:           group.Name,
+				Avatar:         group.GroupAvatar,
+				MemberCount:    int(memberCount),
+				MaxMembers:     config.Group.MaxMembersOf(group.Tier, group.MaxMembers),
+				Category:       group.Category,
 				Announcement:   group.Announcement,
 				Description:    group.Description,
 				Stats:          groupStatus,
@@ -214,12 +229,12 @@ func (s *GroupService) GetMyAllGroups(userId uint) (vo.GroupChatList, error) {
 			})
 		} else if groupMember.Role == Admin {
 			managedGroups = append(managedGroups, vo.GroupChatDetail{
-				ID:          group.ID,
-				Name:        group.Name,
-				Avatar:      group.GroupAvatar,
-				MemberCount: len(members),
-				// todo: 添加群聊统计信息
-				Category:       "游戏交友",
+				ID:             group.ID,
+				Name:           group.Name,
+				Avatar:         group.GroupAvatar,
+				MemberCount:    int(memberCount),
+				MaxMembers:     config.Group.MaxMembersOf(group.Tier, group.MaxMembers),
+				Category:       group.Category,
 				Announcement:   group.Announcement,
 				Description:    group.Description,
 				Stats:          groupStatus,
@@ -227,12 +242,12 @@ func (s *GroupService) GetMyAllGroups(userId uint) (vo.GroupChatList, error) {
 			})
 		} else if groupMember.Role == Member {
 			joinedGroups = append(joinedGroups, vo.GroupChatDetail{
-				ID:          group.ID,
-				Name:        group.Name,
-				Avatar:      group.GroupAvatar,
-				MemberCount: len(members),
-				// todo: 添加群聊统计信息
-				Category:       "游戏交友",
+				ID:             group.ID,
+				Name:           group.Name,
+				Avatar:         group.GroupAvatar,
+				MemberCount:    int(memberCount),
+				MaxMembers:     config.Group.MaxMembersOf(group.Tier, group.MaxMembers),
+				Category:       group.Category,
 				Announcement:   group.Announcement,
 				Description:    group.Description,
 				Stats:          groupStatus,
@@ -264,7 +279,7 @@ func (s *GroupService) GetGroupMembers(groupId string) ([]vo.UserVO, error) {
 		userVOs = append(userVOs, vo.UserVO{
 			ID:          user.ID,
 			Username:    user.Username,
-			Email:       user.Email,
+			Email:       stringValue(user.Email),
 			PhoneNumber: user.PhoneNumber,
 			AvatarURL:   user.AvatarURL,
 			Bio:         user.Bio,
@@ -273,6 +288,9 @@ func (s *GroupService) GetGroupMembers(groupId string) ([]vo.UserVO, error) {
 			UpdatedAt:   user.UpdatedAt,
 			City:        user.City,
 			Role:        groupMember.Role, // 添加角色字段
+			Nickname:    groupMember.Nickname,
+			Title:       groupMember.Title,
+			Level:       groupMember.Level,
 		})
 	}
 	return userVOs, nil
@@ -283,98 +301,182 @@ func (s *GroupService) InviteGroup(userId uint, groupId uint, friendIds []uint)
 	// 查询群聊信息
 	var group db.Group
 	if err := s.db.First(&group, groupId).Error; err != nil {
-		return err
+		return errors.New("群组不存在")
 	}
-	// 判断用户是否是群主或管理员
-	var groupMember db.GroupMember
-	if err := s.db.Where("group_id = ? AND user_id =?", groupId, userId).First(&groupMember).Error; err != nil {
+	// 判断用户是否有权限邀请
+	if _, err := s.authorize(s.db, groupId, userId, ActionInviteMember); err != nil {
 		return err
 	}
-	if groupMember.Role != Owner && groupMember.Role != Admin {
-		return errors.New("只有群主或管理员可以邀请好友加入群聊")
-	}
 
 	// 判断好友是否已经在群聊中,如果在群聊中，不拉入该好友，否则拉入群聊
-	for _, friendId := range friendIds {
-		var member db.GroupMember
-		if err := s.db.Where("group_id =? AND user_id =?", groupId, friendId).First(&member).Error; err == nil {
-			continue
-		}
-		// 查询好友的基本信息
-		var friend db.User
-		if err := s.db.First(&friend, friendId).Error; err != nil {
-			return err
-		}
-		// 好友不在群聊中，加入群聊
-		member = db.GroupMember{
-			GroupID:  groupId,
-			UserID:   friendId,
-			Nickname: friend.Username,
-			Role:     Member,
-			Level:    1,
-		}
-		if err := s.db.Create(&member).Error; err != nil {
-			return err
+	var change membershipChange
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		joined := make([]uint, 0, len(friendIds))
+		for _, friendId := range friendIds {
+			if err := s.joinGroup(tx, group, friendId); err != nil {
+				if errors.Is(err, errAlreadyMember) {
+					continue
+				}
+				return err
+			}
+			joined = append(joined, friendId)
+			if err := recordAudit(tx, auditEntry{
+				Action:   AuditInviteMember,
+				GroupID:  groupId,
+				ActorID:  userId,
+				TargetID: friendId,
+				After:    map[string]string{"role": Member},
+				Message:  "邀请成员入群",
+			}); err != nil {
+				return err
+			}
 		}
+
+		var err error
+		change, err = s.recordMembershipChange(tx, group, MemberJoined, userId, joined)
+		return err
+	})
+	if err != nil {
+		return err
 	}
+	s.invalidateGroupStats(groupId)
+	s.publishMembershipChange(change)
 	return nil
 }
 
+// errAlreadyMember 用户已经是群组成员
+var errAlreadyMember = errors.New("该用户已经是群组成员")
+
+// joinGroup 将用户加入群聊，邀请入群和邀请链接入群共用这一套校验
+func (s *GroupService) joinGroup(tx *gorm.DB, group db.Group, userId uint) error {
+	// 锁定群记录，避免并发入群超出成员上限，也避免同一个用户被并发重复加入
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&group, group.ID).Error; err != nil {
+		return errors.New("群组不存在")
+	}
+	// 判断用户是否已经在群聊中，必须在加锁之后检查
+	var member db.GroupMember
+	err := tx.Where("group_id = ? AND user_id = ?", group.ID, userId).First(&member).Error
+	if err == nil {
+		return errAlreadyMember
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	var memberCount int64
+	if err := tx.Model(&db.GroupMember{}).Where("group_id = ?", group.ID).Count(&memberCount).Error; err != nil {
+		return err
+	}
+	maxMembers := config.Group.MaxMembersOf(group.Tier, group.MaxMembers)
+	if int(memberCount) >= maxMembers {
+		return fmt.Errorf("群成员已达上限(%d人)", maxMembers)
+	}
+	// 查询用户的基本信息
+	var user db.User
+	if err := tx.First(&user, userId).Error; err != nil {
+		return err
+	}
+	// 用户不在群聊中，加入群聊
+	member = db.GroupMember{
+		GroupID:  group.ID,
+		UserID:   userId,
+		Nickname: user.Username,
+		Role:     Member,
+		Level:    1,
+	}
+	return tx.Create(&member).Error
+}
+
 // UpdateMemberRole 更新群成员角色
-func (s *GroupService) UpdateMemberRole(groupID, memberID uint, role string) error {
+func (s *GroupService) UpdateMemberRole(groupID, operatorID, memberID uint, role string) error {
 	// 检查群组是否存在
 	var group db.Group
 	if err := s.db.First(&group, groupID).Error; err != nil {
 		return errors.New("群组不存在")
 	}
 
-	// 检查成员是否在群组中
-	var member db.GroupMember
-	if err := s.db.Where("group_id = ? AND user_id = ?", groupID, memberID).First(&member).Error; err != nil {
-		return errors.New("该用户不是群组成员")
-	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// 检查操作者是否有权限修改该成员的角色
+		actor, member, err := s.authorizeOn(tx, groupID, operatorID, memberID, ActionChangeRole)
+		if err != nil {
+			return err
+		}
 
-	// 检查角色是否有效
-	validRoles := map[string]bool{
-		"owner":  true,
-		"admin":  true,
-		"member": true,
-	}
-	if !validRoles[role] {
-		return errors.New("无效的角色")
-	}
+		// 检查角色是否可以授予
+		if !CanAssignRole(actor.Role, role) {
+			return &ForbiddenError{Message: "无效的角色或没有权限授予该角色"}
+		}
+		if member.Role == role {
+			return nil
+		}
 
-	// 更新成员角色
-	if err := s.db.Model(&member).Update("role", role).Error; err != nil {
-		return err
-	}
+		// 检查管理员数量是否超出上限
+		if role == Admin {
+			var adminCount int64
+			if err := tx.Model(&db.GroupMember{}).Where("group_id = ? AND role = ?", groupID, Admin).Count(&adminCount).Error; err != nil {
+				return err
+			}
+			if int(adminCount) >= config.Group.MaxAdmins {
+				return fmt.Errorf("群管理员已达上限(%d人)", config.Group.MaxAdmins)
+			}
+		}
 
-	return nil
+		// 更新成员角色
+		if err := tx.Model(&member).Update("role", role).Error; err != nil {
+			return err
+		}
+
+		return recordAudit(tx, auditEntry{
+			Action:   AuditChangeRole,
+			GroupID:  groupID,
+			ActorID:  operatorID,
+			TargetID: memberID,
+			Before:   map[string]string{"role": member.Role},
+			After:    map[string]string{"role": role},
+			Message:  fmt.Sprintf("将成员角色从 %s 修改为 %s", member.Role, role),
+		})
+	})
 }
 
 // RemoveMember 移除群成员
-func (s *GroupService) RemoveMember(groupID, memberID uint) error {
+func (s *GroupService) RemoveMember(groupID, operatorID, memberID uint) error {
 	// 检查群组是否存在
 	var group db.Group
 	if err := s.db.First(&group, groupID).Error; err != nil {
 		return errors.New("群组不存在")
 	}
 
-	// 检查要移除的成员是否存在
-	var member db.GroupMember
-	if err := s.db.Where("group_id = ? AND user_id = ?", groupID, memberID).First(&member).Error; err != nil {
-		return errors.New("该用户不是群组成员")
-	}
+	var change membershipChange
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 检查操作者是否有权限移除该成员，群主不能被移除
+		_, member, err := s.authorizeOn(tx, groupID, operatorID, memberID, ActionRemoveMember)
+		if err != nil {
+			return err
+		}
 
-	// 不能移除群主
-	if member.Role == Owner {
-		return errors.New("不能移除群主")
-	}
+		// 删除群成员记录
+		if err := tx.Where("group_id = ? AND user_id = ?", groupID, memberID).Delete(&db.GroupMember{}).Error; err != nil {
+			return err
+		}
 
-	// 删除群成员记录
-	if err := s.db.Where("group_id = ? AND user_id = ?", groupID, memberID).Delete(&db.GroupMember{}).Error; err != nil {
+		if err := recordAudit(tx, auditEntry{
+			Action:   AuditRemoveMember,
+			GroupID:  groupID,
+			ActorID:  operatorID,
+			TargetID: memberID,
+			Before:   map[string]string{"role": member.Role, "nickname": member.Nickname},
+			Message:  "移除群成员",
+		}); err != nil {
+			return err
+		}
+
+		change, err = s.recordMembershipChange(tx, group, MemberRemoved, operatorID, []uint{memberID})
+		return err
+	})
+	if err != nil {
 		return err
 	}
+	s.invalidateGroupStats(groupID)
+	s.publishMembershipChange(change)
 
 	return nil
 }
@@ -387,21 +489,30 @@ func (s *GroupService) QuitGroup(groupID, userID uint) error {
 		return errors.New("群组不存在")
 	}
 
-	// 检查用户是否是群组成员
-	var member db.GroupMember
-	if err := s.db.Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error; err != nil {
-		return errors.New("您不是该群组成员")
-	}
-
-	// 群主不能退出群聊
-	if member.Role == Owner {
-		return errors.New("群主不能退出群聊，请先转让群主或解散群聊")
+	// 检查用户是否是群组成员，群主不能退出群聊
+	member, err := s.authorize(s.db, groupID, userID, ActionQuitGroup)
+	if err != nil {
+		if member.Role == Owner {
+			return errors.New("群主不能退出群聊，请先转让群主或解散群聊")
+		}
+		return err
 	}
 
-	// 删除群成员记录
-	if err := s.db.Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&db.GroupMember{}).Error; err != nil {
+	// 删除群成员记录，并在群聊中留下退出的系统消息
+	var change membershipChange
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&db.GroupMember{}).Error; err != nil {
+			return err
+		}
+		var err error
+		change, err = s.recordMembershipChange(tx, group, MemberQuit, userID, []uint{userID})
+		return err
+	})
+	if err != nil {
 		return err
 	}
+	s.invalidateGroupStats(groupID)
+	s.publishMembershipChange(change)
 
 	return nil
 }
@@ -412,6 +523,7 @@ func (s *GroupService) UpdateGroup(groupID uint, userID uint, updateData struct
 	GroupAvatar  string `json:"group_avatar"`
 	Announcement string `json:"announcement"`
 	Description  string `json:"description"`
+	Category     string `json:"category"`
 }) error {
 	// 检查群组是否存在
 	var group db.Group
@@ -419,15 +531,9 @@ func (s *GroupService) UpdateGroup(groupID uint, userID uint, updateData struct
 		return errors.New("群组不存在")
 	}
 
-	// 检查用户是否是群组成员
-	var member db.GroupMember
-	if err := s.db.Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error; err != nil {
-		return errors.New("您不是该群组成员")
-	}
-
-	// 只有群主和管理员可以更新群信息
-	if member.Role != Owner && member.Role != Admin {
-		return errors.New("只有群主和管理员可以更新群信息")
+	// 检查用户是否有权限更新群信息
+	if _, err := s.authorize(s.db, groupID, userID, ActionUpdateGroup); err != nil {
+		return err
 	}
 
 	// 更新群组信息
@@ -438,21 +544,58 @@ func (s *GroupService) UpdateGroup(groupID uint, userID uint, updateData struct
 	if updateData.GroupAvatar != "" {
 		updates["group_avatar"] = updateData.GroupAvatar
 	}
-	if updateData.Announcement != "" {
-		updates["announcement"] = updateData.Announcement
-	}
 	if updateData.Description != "" {
 		updates["description"] = updateData.Description
 	}
+	if updateData.Category != "" {
+		updates["category"] = updateData.Category
+	}
 
-	if err := s.db.Model(&group).Updates(updates).Error; err != nil {
-		return err
+	if len(updates) > 0 {
+		// 记录修改前的值，与修改后的值一起写入审计日志
+		before := map[string]interface{}{}
+		for field := range updates {
+			before[field] = groupField(group, field)
+		}
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&group).Updates(updates).Error; err != nil {
+				return err
+			}
+			return recordAudit(tx, auditEntry{
+				Action:  AuditUpdateGroup,
+				GroupID: groupID,
+				ActorID: userID,
+				Before:  before,
+				After:   updates,
+				Message: "修改群资料",
+			})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// 公告作为一条新的群公告发布，保留历史并推送给群成员
+	if updateData.Announcement != "" && updateData.Announcement != group.Announcement {
+		if _, err := s.PublishAnnouncement(groupID, userID, updateData.Announcement, false, false); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// GetDB 获取数据库连接
-func (s *GroupService) GetDB() *gorm.DB {
-	return s.db
+// groupField 获取群资料中可修改字段的当前值
+func groupField(group db.Group, field string) string {
+	switch field {
+	case "name":
+		return group.Name
+	case "group_avatar":
+		return group.GroupAvatar
+	case "description":
+		return group.Description
+	case "category":
+		return group.Category
+	}
+	return ""
 }
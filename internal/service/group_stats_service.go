@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"im-system/internal/config"
+	"im-system/internal/model/db"
+	"im-system/internal/model/vo"
+	"time"
+)
+
+// groupStatsCache 缓存的群统计信息，本地人数依赖查看者所在城市，因此缓存按城市分组的人数
+type groupStatsCache struct {
+	Active       int                `json:"active"`
+	Genders      map[string]int     `json:"genders"`
+	Cities       map[string]int     `json:"cities"`
+	MessageTrend []vo.MessageVolume `json:"message_trend"`
+}
+
+// GetRedisGroupStatsKey 获取群统计信息的 Redis key
+func GetRedisGroupStatsKey(groupId uint) string {
+	return fmt.Sprintf("group:stats:%d", groupId)
+}
+
+// getGroupStats 获取群统计信息，viewerCity 为查看者所在城市，用于计算本地人数
+func (s *GroupService) getGroupStats(groupId uint, viewerCity string) (vo.GroupStats, error) {
+	cache, err := s.loadGroupStats(groupId)
+	if err != nil {
+		return vo.GroupStats{}, err
+	}
+
+	stats := vo.GroupStats{
+		Active:       cache.Active,
+		Male:         cache.Genders["male"],
+		Female:       cache.Genders["female"],
+		Other:        cache.Genders["other"],
+		MessageTrend: cache.MessageTrend,
+	}
+	if viewerCity != "" {
+		stats.Local = cache.Cities[viewerCity]
+	}
+	return stats, nil
+}
+
+// loadGroupStats 优先从 Redis 读取群统计信息，缓存未命中时从 MySQL 计算并写入缓存
+func (s *GroupService) loadGroupStats(groupId uint) (groupStatsCache, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	key := GetRedisGroupStatsKey(groupId)
+	if cached, err := config.RedisClient.Get(ctx, key).Result(); err == nil {
+		var cache groupStatsCache
+		if err := json.Unmarshal([]byte(cached), &cache); err == nil {
+			return cache, nil
+		}
+	}
+
+	cache, err := s.computeGroupStats(groupId)
+	if err != nil {
+		return groupStatsCache{}, err
+	}
+
+	marshal, err := json.Marshal(cache)
+	if err != nil {
+		return cache, nil
+	}
+	ttl := time.Duration(config.Group.StatsCacheTTL) * time.Second
+	if err := config.RedisClient.Set(ctx, key, marshal, ttl).Err(); err != nil {
+		config.Logger.Error(err)
+	}
+	return cache, nil
+}
+
+// computeGroupStats 从 MySQL 计算群统计信息
+func (s *GroupService) computeGroupStats(groupId uint) (groupStatsCache, error) {
+	cache := groupStatsCache{
+		Genders:      map[string]int{},
+		Cities:       map[string]int{},
+		MessageTrend: make([]vo.MessageVolume, 0),
+	}
+
+	// 按性别统计成员人数
+	var genders []struct {
+		Gender string
+		Total  int
+	}
+	if err := s.db.Table("group_members").
+		Select("users.gender AS gender, COUNT(*) AS total").
+		Joins("JOIN users ON users.id = group_members.user_id").
+		Where("group_members.group_id = ?", groupId).
+		Group("users.gender").
+		Scan(&genders).Error; err != nil {
+		return cache, err
+	}
+	for _, g := range genders {
+		cache.Genders[g.Gender] = g.Total
+	}
+
+	// 按城市统计成员人数
+	var cities []struct {
+		City  string
+		Total int
+	}
+	if err := s.db.Table("group_members").
+		Select("users.city AS city, COUNT(*) AS total").
+		Joins("JOIN users ON users.id = group_members.user_id").
+		Where("group_members.group_id = ? AND users.city <> ''", groupId).
+		Group("users.city").
+		Scan(&cities).Error; err != nil {
+		return cache, err
+	}
+	for _, c := range cities {
+		cache.Cities[c.City] = c.Total
+	}
+
+	since := time.Now().AddDate(0, 0, -config.Group.ActiveDays)
+
+	// 统计最近一段时间内发过言的成员人数
+	var active int64
+	if err := s.db.Model(&db.Message{}).
+		Where("receiver_group_id = ? AND created_at >= ? AND message_type <> ?", groupId, since, "system").
+		Where("sender_id IN (?)", s.db.Model(&db.GroupMember{}).Select("user_id").Where("group_id = ?", groupId)).
+		Distinct("sender_id").
+		Count(&active).Error; err != nil {
+		return cache, err
+	}
+	cache.Active = int(active)
+
+	// 统计最近一段时间内每天的消息量
+	if err := s.db.Model(&db.Message{}).
+		Select("DATE_FORMAT(created_at, '%Y-%m-%d') AS date, COUNT(*) AS count").
+		Where("receiver_group_id = ? AND created_at >= ? AND message_type <> ?", groupId, since, "system").
+		Group("date").
+		Order("date").
+		Scan(&cache.MessageTrend).Error; err != nil {
+		return cache, err
+	}
+
+	return cache, nil
+}
+
+// invalidateGroupStats 群成员变动时删除群统计缓存
+func (s *GroupService) invalidateGroupStats(groupId uint) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := config.RedisClient.Del(ctx, GetRedisGroupStatsKey(groupId)).Err(); err != nil {
+		config.Logger.Error(err)
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"im-system/internal/config"
+	"im-system/internal/utils"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditLoginFailed 登录失败的审计日志类型
+const AuditLoginFailed = "login_failed"
+
+// 登录失败原因，记录在审计日志中
+const (
+	loginFailUserNotFound  = "user_not_found" // 用户不存在
+	loginFailWrongPassword = "wrong_password" // 密码错误
+	loginFailWrongCode     = "wrong_code"     // 两步验证码或恢复码错误
+)
+
+// errLoginFailed 用户不存在和密码错误统一返回的错误，避免被用来枚举账号
+var errLoginFailed = errors.New("账号或密码错误")
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// GetRedisLoginFailKey 获取登录失败计数的 Redis key，scope 为 account 或 ip
+func GetRedisLoginFailKey(scope, value string) string {
+	return fmt.Sprintf("login:fail:%s:%s", scope, value)
+}
+
+// GetRedisLoginLockKey 获取登录锁定标记的 Redis key，scope 为 account 或 ip
+func GetRedisLoginLockKey(scope, value string) string {
+	return fmt.Sprintf("login:lock:%s:%s", scope, value)
+}
+
+// GetRedisLoginDelayKey 获取账号下次允许尝试登录时间的 Redis key
+func GetRedisLoginDelayKey(account string) string {
+	return fmt.Sprintf("login:delay:%s", account)
+}
+
+// checkLoginAllowed 检查账号和IP是否被锁定或仍在等待时间内，Redis 不可用时不限制
+func checkLoginAllowed(account, ip string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	locked, err := config.RedisClient.Exists(ctx,
+		GetRedisLoginLockKey("account", account),
+		GetRedisLoginLockKey("ip", ip)).Result()
+	if err != nil {
+		config.Logger.Error(err)
+		return nil
+	}
+	if locked > 0 {
+		return &TooManyRequestsError{Message: "登录失败次数过多，请稍后再试"}
+	}
+
+	wait, err := config.RedisClient.TTL(ctx, GetRedisLoginDelayKey(account)).Result()
+	if err != nil {
+		config.Logger.Error(err)
+		return nil
+	}
+	if wait > 0 {
+		return &TooManyRequestsError{Message: fmt.Sprintf("登录过于频繁，请 %d 秒后再试", int(wait.Seconds()))}
+	}
+	return nil
+}
+
+// recordLoginFailure 累加账号和IP的失败次数，设置递增的等待时间，达到上限时临时锁定
+func recordLoginFailure(account, ip string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	window := time.Duration(config.Auth.LoginFailureWindow) * time.Second
+	lockout := time.Duration(config.Auth.LoginLockout) * time.Second
+
+	accountFailures := incrCounter(ctx, GetRedisLoginFailKey("account", account), window)
+	if accountFailures > 0 {
+		// 等待时间随失败次数翻倍：1s、2s、4s ... 不超过配置的上限
+		delay := config.Auth.LoginMaxDelay
+		if accountFailures <= 16 && 1<<(accountFailures-1) < delay {
+			delay = 1 << (accountFailures - 1)
+		}
+		if err := config.RedisClient.Set(ctx, GetRedisLoginDelayKey(account), 1, time.Duration(delay)*time.Second).Err(); err != nil {
+			config.Logger.Error(err)
+		}
+	}
+	if accountFailures >= int64(config.Auth.LoginMaxFailures) {
+		lockLogin(ctx, "account", account, lockout)
+	}
+
+	ipFailures := incrCounter(ctx, GetRedisLoginFailKey("ip", ip), window)
+	if ipFailures >= int64(config.Auth.LoginIPMaxFailures) {
+		lockLogin(ctx, "ip", ip, lockout)
+	}
+}
+
+// clearLoginFailures 登录成功后清除账号的失败计数和等待时间，IP 的计数保留到窗口结束
+func clearLoginFailures(account string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := config.RedisClient.Del(ctx,
+		GetRedisLoginFailKey("account", account),
+		GetRedisLoginDelayKey(account)).Err(); err != nil {
+		config.Logger.Error(err)
+	}
+}
+
+// incrCounter 计数加一，第一次计数时设置计数窗口，出错时返回 0
+func incrCounter(ctx context.Context, key string, window time.Duration) int64 {
+	count, err := config.RedisClient.Incr(ctx, key).Result()
+	if err != nil {
+		config.Logger.Error(err)
+		return 0
+	}
+	if count == 1 {
+		if err := config.RedisClient.Expire(ctx, key, window).Err(); err != nil {
+			config.Logger.Error(err)
+		}
+	}
+	return count
+}
+
+// lockLogin 临时锁定账号或IP，并重新开始失败计数
+func lockLogin(ctx context.Context, scope, value string, lockout time.Duration) {
+	pipe := config.RedisClient.TxPipeline()
+	pipe.Set(ctx, GetRedisLoginLockKey(scope, value), 1, lockout)
+	pipe.Del(ctx, GetRedisLoginFailKey(scope, value))
+	if _, err := pipe.Exec(ctx); err != nil {
+		config.Logger.Error(err)
+	}
+}
+
+// recordLoginAudit 记录登录失败的审计日志，userID 为 0 表示账号不存在
+func recordLoginAudit(tx *gorm.DB, userID uint, account, ip, reason string) {
+	entry := auditEntry{
+		Action:  AuditLoginFailed,
+		ActorID: userID,
+		After: map[string]string{
+			"account": account,
+			"ip":      ip,
+			"reason":  reason,
+		},
+		Message: fmt.Sprintf("账号 %s 在 %s 登录失败", account, ip),
+	}
+	if err := recordAudit(tx, entry); err != nil {
+		config.Logger.Error(err)
+	}
+}
+
+// compareDummyPassword 用户不存在时也做一次密码哈希比较，使响应时间与密码错误时一致
+func compareDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		hash, err := utils.HashPassword("dummy-password", config.Auth.BcryptCost)
+		if err != nil {
+			config.Logger.Error(err)
+			return
+		}
+		dummyHash = hash
+	})
+	if dummyHash != "" {
+		utils.ComparePassword(dummyHash, password)
+	}
+}
@@ -1,9 +1,12 @@
 package service
 
 import (
+	"im-system/internal/config"
 	"im-system/internal/model/db"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type MessageService struct {
@@ -33,7 +36,50 @@ func (s *MessageService) SaveGroupMessage(senderID, groupID uint, content string
 		Content:         content,
 		MessageType:     "text",
 	}
-	return s.db.Create(&message).Error
+	if err := s.db.Create(&message).Error; err != nil {
+		return err
+	}
+
+	// 发言后更新成员等级，失败不影响消息发送
+	if err := s.refreshMemberLevel(groupID, senderID); err != nil {
+		config.Logger.Errorf("更新群成员等级失败: %v", err)
+	}
+	return nil
+}
+
+// refreshMemberLevel 累加成员在群中的发言数和活跃天数，并重新计算等级
+func (s *MessageService) refreshMemberLevel(groupID, userID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定成员记录，并发发言时按顺序累加
+		var member db.GroupMember
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "level", "message_count", "active_days", "last_active_date").
+			Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error; err != nil {
+			return err
+		}
+
+		member = recordMemberActivity(member, time.Now())
+		return tx.Model(&member).Updates(map[string]interface{}{
+			"message_count":    member.MessageCount,
+			"active_days":      member.ActiveDays,
+			"last_active_date": member.LastActiveDate,
+			"level":            config.Group.LevelOf(member.MessageCount, member.ActiveDays),
+		}).Error
+	})
+}
+
+// recordMemberActivity 记录成员的一次发言，发言数加一，当天第一次发言时活跃天数加一
+func recordMemberActivity(member db.GroupMember, now time.Time) db.GroupMember {
+	year, month, day := now.Date()
+	member.MessageCount++
+	if member.LastActiveDate == nil {
+		member.ActiveDays++
+	} else if y, m, d := member.LastActiveDate.In(now.Location()).Date(); y != year || m != month || d != day {
+		member.ActiveDays++
+	}
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	member.LastActiveDate = &today
+	return member
 }
 
 // GetGroupMembers 获取群组成员
@@ -43,6 +89,13 @@ func (s *MessageService) GetGroupMembers(groupID int) ([]db.GroupMember, error)
 	return members, err
 }
 
+// GetGroupMember 获取用户在群组中的成员信息
+func (s *MessageService) GetGroupMember(groupID, userID uint) (db.GroupMember, error) {
+	var member db.GroupMember
+	err := s.db.Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error
+	return member, err
+}
+
 // GetChatMessages 获取聊天记录
 func (s *MessageService) GetChatMessages(chatType string, userID, toID uint) ([]db.Message, error) {
 	var messages []db.Message
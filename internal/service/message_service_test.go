@@ -0,0 +1,50 @@
+package service
+
+import (
+	"im-system/internal/model/db"
+	"testing"
+	"time"
+)
+
+func TestRecordMemberActivityDayBoundary(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	day := func(d, h, m int) time.Time { return time.Date(2024, 3, d, h, m, 0, 0, loc) }
+
+	var member db.GroupMember
+	steps := []struct {
+		now        time.Time
+		messages   int
+		activeDays int
+	}{
+		{day(1, 9, 0), 1, 1},   // 第一次发言
+		{day(1, 23, 59), 2, 1}, // 同一天内再次发言
+		{day(2, 0, 0), 3, 2},   // 跨过零点
+		{day(2, 12, 0), 4, 2},  // 同一天内再次发言
+		{day(5, 8, 0), 5, 3},   // 中间隔了几天
+	}
+	for _, step := range steps {
+		member = recordMemberActivity(member, step.now)
+		if member.MessageCount != step.messages || member.ActiveDays != step.activeDays {
+			t.Fatalf("at %s: messages %d, active days %d, want %d, %d",
+				step.now.Format(time.RFC3339), member.MessageCount, member.ActiveDays, step.messages, step.activeDays)
+		}
+		if y, m, d := member.LastActiveDate.Date(); y != step.now.Year() || m != step.now.Month() || d != step.now.Day() {
+			t.Fatalf("at %s: last active date %s", step.now.Format(time.RFC3339), member.LastActiveDate)
+		}
+	}
+}
+
+func TestRecordMemberActivityStoredDate(t *testing.T) {
+	// 数据库中的 DATE 读出来是当天零点，可能与当前时间使用不同的时区
+	stored := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	member := db.GroupMember{MessageCount: 10, ActiveDays: 3, LastActiveDate: &stored}
+
+	member = recordMemberActivity(member, time.Date(2024, 3, 1, 18, 30, 0, 0, time.Local))
+	if member.MessageCount != 11 || member.ActiveDays != 3 {
+		t.Fatalf("same day: messages %d, active days %d, want 11, 3", member.MessageCount, member.ActiveDays)
+	}
+	member = recordMemberActivity(member, time.Date(2024, 3, 2, 0, 0, 1, 0, time.Local))
+	if member.MessageCount != 12 || member.ActiveDays != 4 {
+		t.Fatalf("next day: messages %d, active days %d, want 12, 4", member.MessageCount, member.ActiveDays)
+	}
+}
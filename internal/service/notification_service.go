@@ -1,10 +1,17 @@
 package service
 
 import (
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"im-system/internal/config"
 	"im-system/internal/model/db"
+	"im-system/internal/model/dto"
 	"im-system/internal/model/vo"
+	"im-system/internal/module/hub"
+	"time"
 )
 
 type NotificationService struct {
@@ -20,125 +27,221 @@ func NewNotificationService() *NotificationService {
 const (
 	// DefaultGroupName TODO: 后续优化这个分组
 	DefaultGroupName = "我的好友"
+
+	maxNotificationPageSize = 100 // 通知列表每页最大条数
 )
 
-// GetNotifications 获取用户的通知
-func (s *NotificationService) GetNotifications(userID uint, notificationType string) ([]vo.NotificationVO, error) {
-	var notifications []db.Notification
+// notificationSenderColumns 查询通知中对方用户信息时只读取公开资料字段
+var notificationSenderColumns = []string{"id", "username", "avatar_url", "bio"}
+
+// GetNotifications 分页获取用户的通知，按创建时间倒序，使用游标翻页
+func (s *NotificationService) GetNotifications(userID uint, queryDTO dto.QueryNotificationsDTO) (vo.NotificationPageVO, error) {
+	resp := vo.NotificationPageVO{Items: make([]vo.NotificationVO, 0)}
+
+	limit := queryDTO.Limit
+	if limit <= 0 {
+		limit = config.Notification.PageSize
+	}
+	if limit > maxNotificationPageSize {
+		limit = maxNotificationPageSize
+	}
+
 	query := s.db.Where("receiver_id = ?", userID)
+	// 根据类型、状态和已读状态过滤通知
+	if queryDTO.Type != "" {
+		query = query.Where("type = ?", queryDTO.Type)
+	}
+	if queryDTO.Status != "" {
+		query = query.Where("status = ?", queryDTO.Status)
+	}
+	if queryDTO.IsRead != nil {
+		query = query.Where("is_read = ?", *queryDTO.IsRead)
+	}
+	if queryDTO.Cursor != "" {
+		createdAt, id, err := decodeNotificationCursor(queryDTO.Cursor)
+		if err != nil {
+			return resp, err
+		}
+		query = query.Where("created_at < ? OR (created_at = ? AND id < ?)", createdAt, createdAt, id)
+	}
 
-	// 根据类型过滤通知
-	if notificationType != "" {
-		query = query.Where("type = ?", notificationType)
+	// 多查一条用来判断是否还有下一页
+	var notifications []db.Notification
+	if err := query.Order("created_at desc, id desc").Limit(limit + 1).Find(&notifications).Error; err != nil {
+		return resp, err
+	}
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		last := notifications[limit-1]
+		resp.NextCursor = encodeNotificationCursor(last.CreatedAt, last.ID)
 	}
 
-	if err := query.Find(&notifications).Error; err != nil {
-		return nil, err
+	// 批量查询发送者的信息
+	senderIDs := make([]uint, 0, len(notifications))
+	for _, notification := range notifications {
+		senderIDs = append(senderIDs, notification.SenderID)
+	}
+	var senders []db.User
+	if err := s.db.Select(notificationSenderColumns).Where("id IN ?", senderIDs).Find(&senders).Error; err != nil {
+		return resp, err
+	}
+	senderMap := make(map[uint]db.User, len(senders))
+	for _, sender := range senders {
+		senderMap[sender.ID] = sender
 	}
 
-	// 创建 NotificationVO 列表
-	var notificationVOs []vo.NotificationVO
 	for _, notification := range notifications {
-		var sender db.User
-		// 查询发送添加好友请求人的信息
-		if err := s.db.First(&sender, notification.SenderID).Error; err == nil {
-			notificationVOs = append(notificationVOs, vo.NotificationVO{
-				ID:         notification.ID,
-				UserID:     notification.SenderID,
-				ReceiverID: notification.ReceiverID,
-				Type:       notification.Type,
-				Content:    notification.Content,
-				IsRead:     notification.IsRead,
-				CreatedAt:  notification.CreatedAt,
-				Sender:     sender, // 发送请求的用户信息
-			})
+		sender, ok := senderMap[notification.SenderID]
+		if !ok {
+			continue
 		}
+		resp.Items = append(resp.Items, toNotificationVO(notification, sender))
 	}
-
-	return notificationVOs, nil
+	return resp, nil
 }
 
-// AcceptFriendRequest 接受好友请求
-func (s *NotificationService) AcceptFriendRequest(notificationID uint, groupId uint) error {
+// AcceptFriendRequest 接受好友请求，只有请求的接收者可以处理，重复接受不会报错
+func (s *NotificationService) AcceptFriendRequest(userID, notificationID uint, groupId uint) error {
 	var notification db.Notification
-	// 查询通知
-	if err := s.db.First(&notification, notificationID).Error; err != nil {
-		return errors.New("通知不存在")
-	}
-	// 检查通知是否已读
-	if notification.IsRead {
-		return nil
-	}
+	handled := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		notification, err = lockFriendRequest(tx, userID, notificationID)
+		if err != nil {
+			return err
+		}
+		// 检查通知是否已处理，已读但未处理的请求仍然可以接受
+		switch notification.Status {
+		case "accepted":
+			handled = true
+			return nil
+		case "rejected":
+			return errors.New("该好友请求已被拒绝")
+		case "expired":
+			return errors.New("好友请求已过期")
+		}
+		if friendRequestExpired(notification.CreatedAt) {
+			return errors.New("好友请求已过期")
+		}
 
-	// 更新通知状态为已读
-	notification.IsRead = true
-	notification.Status = "accepted"
-	if err := s.db.Save(&notification).Error; err != nil {
-		return err
-	}
+		// 查询请求者的基本信息
+		var requester db.User
+		if err := tx.First(&requester, notification.SenderID).Error; err != nil {
+			return errors.New("请求者信息不存在")
+		}
 
-	// 查询请求者的基本信息
-	var requester db.User
-	if err := s.db.First(&requester, notification.SenderID).Error; err != nil {
-		return errors.New("请求者信息不存在")
-	}
+		// groupId 为 0 时使用默认的好友分组，否则必须是接受者自己的分组
+		friendGroup := db.FriendGroup{}
+		query := tx.Where("user_id = ?", userID)
+		if groupId == 0 {
+			query = query.Where("group_name = ?", DefaultGroupName)
+		} else {
+			query = query.Where("id = ?", groupId)
+		}
+		if err := query.First(&friendGroup).Error; err != nil {
+			return errors.New("分组不存在")
+		}
 
-	// 如果groupId为0， 则查询当前用户的group的好友分组
-	friendGroup := db.FriendGroup{}
-	if err := s.db.Where("user_id = ? and group_name = ? ", notification.ReceiverID, DefaultGroupName).
-		First(&friendGroup).Error; err != nil {
-		return errors.New("分组不存在")
-	}
-	// 创建被请求者的好友关系
-	friendship := db.Friendship{
-		UserID:   notification.ReceiverID, // 被请求者的用户ID
-		FriendID: notification.SenderID,   // 请求者的用户ID
-		Status:   "accepted",              // 状态为已接受
-		Remark:   requester.Username,      // 使用请求者的用户名作为备注
-		GroupID:  friendGroup.ID,          // 分组ID
-	}
+		// 更新通知状态为已读
+		notification.IsRead = true
+		notification.Status = "accepted"
+		if err := tx.Save(&notification).Error; err != nil {
+			return err
+		}
 
-	if err := s.db.Create(&friendship).Error; err != nil {
-		return err
-	}
-	// 更新请求者的好友关系
-	senderFriendShip := db.Friendship{}
-	if err := s.db.Model(&db.Friendship{}).
-		Where("user_id =? and friend_id =?", notification.SenderID, notification.ReceiverID).
-		First(&senderFriendShip).Error; err != nil {
-		return err
-	}
-	senderFriendShip.Status = "accepted"
-	if err := s.db.Save(&senderFriendShip).Error; err != nil {
+		// 更新请求者的好友关系
+		requesterSide, err := lockFriendship(tx, notification.SenderID, notification.ReceiverID)
+		if err != nil {
+			return err
+		}
+		if requesterSide.Status != FriendshipPending {
+			return errors.New("好友请求已失效")
+		}
+		if err := transitFriendship(tx, &requesterSide, FriendshipAccepted); err != nil {
+			return err
+		}
+
+		// 被请求者的好友关系，不存在时创建，使用请求者的用户名作为备注
+		friendship, err := lockFriendship(tx, notification.ReceiverID, notification.SenderID)
+		if err != nil {
+			return err
+		}
+		if friendship.Status == FriendshipBlocked {
+			return errors.New("您已拉黑对方，请先取消拉黑")
+		}
+		if friendship.Remark == "" {
+			friendship.Remark = requester.Username
+		}
+		friendship.GroupID = friendGroup.ID
+		if friendship.Status == FriendshipAccepted {
+			return tx.Save(&friendship).Error
+		}
+		return transitFriendship(tx, &friendship, FriendshipAccepted)
+	})
+	if err != nil || handled {
 		return err
 	}
+	pushNotificationUpdate(s.db, notification)
 	return nil
 }
 
-// RejectFriendRequest 拒绝好友请求
-func (s *NotificationService) RejectFriendRequest(notificationID uint) error {
+// RejectFriendRequest 拒绝好友请求，只有请求的接收者可以处理，重复拒绝不会报错
+func (s *NotificationService) RejectFriendRequest(userID, notificationID uint) error {
 	var notification db.Notification
-	if err := s.db.First(&notification, notificationID).Error; err != nil {
-		return errors.New("通知不存在")
-	}
+	handled := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		notification, err = lockFriendRequest(tx, userID, notificationID)
+		if err != nil {
+			return err
+		}
+		switch notification.Status {
+		case "rejected", "expired":
+			handled = true
+			return nil
+		case "accepted":
+			return errors.New("该好友请求已被接受")
+		}
 
-	// 更新通知状态为已读
-	notification.IsRead = true
-	notification.Status = "rejected"
-	if err := s.db.Save(&notification).Error; err != nil {
-		return err
-	}
+		// 更新通知状态为已读
+		notification.IsRead = true
+		notification.Status = "rejected"
+		if err := tx.Save(&notification).Error; err != nil {
+			return err
+		}
 
-	// 这里可以添加其他拒绝请求的逻辑，将发送者创建的friendship删除
-	if err := s.db.Model(&db.Friendship{}).
-		Where("user_id =? and friend_id =?", notification.SenderID, notification.ReceiverID).
-		Delete(&db.Friendship{}).Error; err != nil {
+		// 发送者的待处理好友关系标记为被拒绝，再次请求时复用
+		requesterSide, err := lockFriendship(tx, notification.SenderID, notification.ReceiverID)
+		if err != nil {
+			return err
+		}
+		if requesterSide.Status != FriendshipPending {
+			return nil
+		}
+		return transitFriendship(tx, &requesterSide, FriendshipRejected)
+	})
+	if err != nil || handled {
 		return err
 	}
-
+	pushNotificationUpdate(s.db, notification)
 	return nil
 }
 
+// lockFriendRequest 锁定好友请求通知，并校验当前用户是请求的接收者
+func lockFriendRequest(tx *gorm.DB, userID, notificationID uint) (db.Notification, error) {
+	var notification db.Notification
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&notification, notificationID).Error; err != nil {
+		return notification, errors.New("通知不存在")
+	}
+	if notification.Type != "friend_request" {
+		return notification, errors.New("该通知不是好友请求")
+	}
+	if notification.ReceiverID != userID {
+		return notification, &ForbiddenError{Message: "无权处理该好友请求"}
+	}
+	return notification, nil
+}
+
 // GetSentNotifications 获取用户发出的所有通知请求
 func (s *NotificationService) GetSentNotifications(userID uint) ([]vo.NotificationVO, error) {
 	var notifications []db.Notification
@@ -151,7 +254,7 @@ func (s *NotificationService) GetSentNotifications(userID uint) ([]vo.Notificati
 	for _, notification := range notifications {
 		var receiver db.User
 		// 查询接收者的信息
-		if err := s.db.First(&receiver, notification.ReceiverID).Error; err == nil {
+		if err := s.db.Select(notificationSenderColumns).First(&receiver, notification.ReceiverID).Error; err == nil {
 			notificationVOs = append(notificationVOs, vo.NotificationVO{
 				ID:         notification.ID,
 				UserID:     notification.SenderID,
@@ -161,7 +264,7 @@ func (s *NotificationService) GetSentNotifications(userID uint) ([]vo.Notificati
 				IsRead:     notification.IsRead,
 				Status:     notification.Status,
 				CreatedAt:  notification.CreatedAt,
-				Sender:     receiver, // 接收者的信息
+				Sender:     toNotificationSenderVO(receiver), // 接收者的信息
 			})
 		}
 	}
@@ -186,7 +289,7 @@ func (s *NotificationService) GetFriendRequestNotifications(userID uint) ([]vo.N
 		} else {
 			searchId = notification.ReceiverID
 		}
-		if err := s.db.First(&receiver, searchId).Error; err == nil {
+		if err := s.db.Select(notificationSenderColumns).First(&receiver, searchId).Error; err == nil {
 			notificationVOs = append(notificationVOs, vo.NotificationVO{
 				ID:         notification.ID,
 				UserID:     notification.SenderID,
@@ -196,10 +299,191 @@ func (s *NotificationService) GetFriendRequestNotifications(userID uint) ([]vo.N
 				IsRead:     notification.IsRead,
 				Status:     notification.Status,
 				CreatedAt:  notification.CreatedAt,
-				Sender:     receiver, //
+				Sender:     toNotificationSenderVO(receiver),
 			})
 		}
 	}
 
 	return notificationVOs, err
 }
+
+// GetUnreadCount 获取用户的未读通知数
+func (s *NotificationService) GetUnreadCount(userID uint) (vo.NotificationUnreadVO, error) {
+	count, err := countUnread(s.db, userID)
+	return vo.NotificationUnreadVO{Count: count}, err
+}
+
+// MarkAsRead 将用户的指定通知标记为已读，只能标记自己收到的通知
+func (s *NotificationService) MarkAsRead(userID uint, notificationIDs []uint) error {
+	if len(notificationIDs) == 0 {
+		return errors.New("通知ID不能为空")
+	}
+	if err := s.db.Model(&db.Notification{}).
+		Where("receiver_id = ? AND id IN ? AND is_read = ?", userID, notificationIDs, false).
+		Update("is_read", true).Error; err != nil {
+		return err
+	}
+	pushUnreadCount(s.db, userID)
+	return nil
+}
+
+// MarkAllAsRead 将用户的所有通知标记为已读
+func (s *NotificationService) MarkAllAsRead(userID uint) error {
+	if err := s.db.Model(&db.Notification{}).
+		Where("receiver_id = ? AND is_read = ?", userID, false).
+		Update("is_read", true).Error; err != nil {
+		return err
+	}
+	pushUnreadCount(s.db, userID)
+	return nil
+}
+
+// DeleteNotifications 删除用户收到的指定通知
+func (s *NotificationService) DeleteNotifications(userID uint, notificationIDs []uint) error {
+	if len(notificationIDs) == 0 {
+		return errors.New("通知ID不能为空")
+	}
+	if err := s.db.Where("receiver_id = ? AND id IN ?", userID, notificationIDs).Delete(&db.Notification{}).Error; err != nil {
+		return err
+	}
+	pushUnreadCount(s.db, userID)
+	return nil
+}
+
+// ClearNotifications 清空用户收到的通知，未处理的好友请求会保留
+func (s *NotificationService) ClearNotifications(userID uint) error {
+	if err := s.db.Where("receiver_id = ? AND NOT (type = ? AND status = ?)", userID, "friend_request", "pending").
+		Delete(&db.Notification{}).Error; err != nil {
+		return err
+	}
+	pushUnreadCount(s.db, userID)
+	return nil
+}
+
+// StartRetentionJob 定期处理过期的好友请求，并清理超过保留天数的通知，需要在单独的 goroutine 中运行
+func (s *NotificationService) StartRetentionJob() {
+	ticker := time.NewTicker(time.Duration(config.Notification.CleanupInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		s.purgeExpiredNotifications()
+		<-ticker.C
+	}
+}
+
+// purgeExpiredNotifications 将过期的好友请求标记为过期，并物理删除超过保留天数的通知。
+// 只有未处理的好友请求不会被删除，其他类型的通知即使一直是 pending 状态也会被清理
+func (s *NotificationService) purgeExpiredNotifications() {
+	if err := expireFriendRequests(s.db); err != nil {
+		config.Logger.Errorf("处理过期好友请求失败: %v", err)
+	}
+
+	before := time.Now().AddDate(0, 0, -config.Notification.RetentionDays)
+	result := s.db.Unscoped().
+		Where("created_at < ? AND (type <> ? OR status <> ? OR deleted_at IS NOT NULL)", before, "friend_request", "pending").
+		Delete(&db.Notification{})
+	if result.Error != nil {
+		config.Logger.Errorf("清理过期通知失败: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		config.Logger.Infof("清理过期通知 %d 条", result.RowsAffected)
+	}
+}
+
+// encodeNotificationCursor 将最后一条通知的创建时间和ID编码为游标
+func encodeNotificationCursor(createdAt time.Time, id uint) string {
+	raw := fmt.Sprintf("%d_%d", createdAt.UnixNano(), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeNotificationCursor 解析游标
+func decodeNotificationCursor(cursor string) (time.Time, uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, errors.New("无效的分页游标")
+	}
+	var nanos int64
+	var id uint
+	if _, err := fmt.Sscanf(string(raw), "%d_%d", &nanos, &id); err != nil {
+		return time.Time{}, 0, errors.New("无效的分页游标")
+	}
+	return time.Unix(0, nanos), id, nil
+}
+
+// countUnread 统计用户的未读通知数
+func countUnread(tx *gorm.DB, userID uint) (int64, error) {
+	var count int64
+	err := tx.Model(&db.Notification{}).Where("receiver_id = ? AND is_read = ?", userID, false).Count(&count).Error
+	return count, err
+}
+
+// toNotificationSenderVO 只保留通知中需要展示的公开资料
+func toNotificationSenderVO(user db.User) vo.NotificationSenderVO {
+	return vo.NotificationSenderVO{
+		ID:        user.ID,
+		Username:  user.Username,
+		AvatarURL: user.AvatarURL,
+		Bio:       user.Bio,
+	}
+}
+
+// toNotificationVO 转换为通知视图对象，user 为展示在通知中的对方用户
+func toNotificationVO(notification db.Notification, user db.User) vo.NotificationVO {
+	return vo.NotificationVO{
+		ID:         notification.ID,
+		UserID:     notification.SenderID,
+		ReceiverID: notification.ReceiverID,
+		Type:       notification.Type,
+		Content:    notification.Content,
+		IsRead:     notification.IsRead,
+		Status:     notification.Status,
+		GroupID:    notification.GroupID,
+		CreatedAt:  notification.CreatedAt,
+		Sender:     toNotificationSenderVO(user),
+	}
+}
+
+// pushNotification 将新通知实时推送给在线的接收者，并同步未读数
+func pushNotification(tx *gorm.DB, notification db.Notification) {
+	var sender db.User
+	if err := tx.Select(notificationSenderColumns).First(&sender, notification.SenderID).Error; err != nil {
+		config.Logger.Error(err)
+		return
+	}
+	pushNotificationFrom(tx, notification, sender)
+}
+
+// pushNotificationFrom 使用已经查询好的发送者推送新通知，sender 只需要包含公开资料字段
+func pushNotificationFrom(tx *gorm.DB, notification db.Notification, sender db.User) {
+	hub.Default.PushEvent([]uint{notification.ReceiverID}, hub.EventNotification, toNotificationVO(notification, sender))
+	pushUnreadCount(tx, notification.ReceiverID)
+}
+
+// pushNotificationUpdate 通知状态变化时推送给发送者和接收者，每一方看到的都是对方的信息
+func pushNotificationUpdate(tx *gorm.DB, notification db.Notification) {
+	var sender, receiver db.User
+	if err := tx.Select(notificationSenderColumns).First(&sender, notification.SenderID).Error; err != nil {
+		config.Logger.Error(err)
+		return
+	}
+	if err := tx.Select(notificationSenderColumns).First(&receiver, notification.ReceiverID).Error; err != nil {
+		config.Logger.Error(err)
+		return
+	}
+	hub.Default.PushEvent([]uint{notification.ReceiverID}, hub.EventNotificationUpdate, toNotificationVO(notification, sender))
+	hub.Default.PushEvent([]uint{notification.SenderID}, hub.EventNotificationUpdate, toNotificationVO(notification, receiver))
+	pushUnreadCount(tx, notification.ReceiverID)
+}
+
+// pushUnreadCount 将用户最新的未读通知数推送给其所有在线设备
+func pushUnreadCount(tx *gorm.DB, userID uint) {
+	if !hub.Default.IsOnline(userID) {
+		return
+	}
+	count, err := countUnread(tx, userID)
+	if err != nil {
+		config.Logger.Error(err)
+		return
+	}
+	hub.Default.PushEvent([]uint{userID}, hub.EventNotificationUnread, vo.NotificationUnreadVO{Count: count})
+}
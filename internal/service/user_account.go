@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"im-system/internal/model/db"
+	"im-system/internal/module/usercache"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// handlePattern 账号名以字母开头，只包含字母、数字和下划线，长度 4~32，不会与手机号和邮箱混淆
+	handlePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{3,31}$`)
+	// phonePattern 手机号只包含数字，可以带国际区号前缀 +
+	phonePattern = regexp.MustCompile(`^\+?[0-9]{5,20}$`)
+)
+
+// accountColumn 根据登录账号的格式判断是手机号、邮箱还是账号名，返回对应的列名
+func accountColumn(account string) string {
+	switch {
+	case strings.Contains(account, "@"):
+		return "email"
+	case phonePattern.MatchString(account):
+		return "phone_number"
+	default:
+		return "handle"
+	}
+}
+
+// findUserByAccount 通过手机号、邮箱或账号名查找用户
+func findUserByAccount(tx *gorm.DB, account string) (db.User, error) {
+	var user db.User
+	err := tx.Where(accountColumn(account)+" = ?", account).First(&user).Error
+	return user, err
+}
+
+// normalizeEmail 校验并规范化邮箱，为空时返回 nil
+func normalizeEmail(email *string) (*string, error) {
+	if email == nil || strings.TrimSpace(*email) == "" {
+		return nil, nil
+	}
+	address, err := mail.ParseAddress(strings.TrimSpace(*email))
+	if err != nil || address.Name != "" {
+		return nil, errors.New("邮箱格式不正确")
+	}
+	normalized := strings.ToLower(address.Address)
+	return &normalized, nil
+}
+
+// normalizeHandle 校验账号名，为空时返回 nil
+func normalizeHandle(handle *string) (*string, error) {
+	if handle == nil || strings.TrimSpace(*handle) == "" {
+		return nil, nil
+	}
+	normalized := strings.TrimSpace(*handle)
+	if !handlePattern.MatchString(normalized) {
+		return nil, errors.New("账号名需以字母开头，只能包含字母、数字和下划线，长度为4~32")
+	}
+	return &normalized, nil
+}
+
+// checkAccountAvailable 检查手机号、邮箱或账号名是否已被其他用户使用，excludeID 为当前用户ID
+func checkAccountAvailable(tx *gorm.DB, column, value string, excludeID uint) error {
+	var count int64
+	if err := tx.Model(&db.User{}).Where(column+" = ? AND id <> ?", value, excludeID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+	switch column {
+	case "email":
+		return errors.New("邮箱已经被注册")
+	case "handle":
+		return errors.New("账号名已经被使用")
+	default:
+		return errors.New("手机号已经被注册")
+	}
+}
+
+// stringValue 返回可为空字符串字段的值，为空时返回空字符串
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// ChangePhone 修改手机号，需要提供发送到新手机号的验证码
+func (s *UserService) ChangePhone(userID uint, phoneNumber, code string) error {
+	phoneNumber = strings.TrimSpace(phoneNumber)
+	if !phonePattern.MatchString(phoneNumber) {
+		return errors.New("手机号格式不正确")
+	}
+	if err := checkAccountAvailable(s.db, "phone_number", phoneNumber, userID); err != nil {
+		return err
+	}
+	if err := s.verification.VerifyCode(VerifyChangePhone, phoneNumber, code); err != nil {
+		return err
+	}
+	if err := s.db.Model(&db.User{}).Where("id = ?", userID).Update("phone_number", phoneNumber).Error; err != nil {
+		return err
+	}
+	usercache.Invalidate(userID)
+	return nil
+}
+
+// ChangeEmail 修改邮箱，需要提供发送到新邮箱的验证码
+func (s *UserService) ChangeEmail(userID uint, email, code string) error {
+	normalized, err := normalizeEmail(&email)
+	if err != nil {
+		return err
+	}
+	if normalized == nil {
+		return errors.New("邮箱不能为空")
+	}
+	if err := checkAccountAvailable(s.db, "email", *normalized, userID); err != nil {
+		return err
+	}
+	if err := s.verification.VerifyCode(VerifyChangeEmail, *normalized, code); err != nil {
+		return err
+	}
+	if err := s.db.Model(&db.User{}).Where("id = ?", userID).Update("email", *normalized).Error; err != nil {
+		return err
+	}
+	usercache.Invalidate(userID)
+	return nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"im-system/internal/config"
+	"im-system/internal/middle"
+	"im-system/internal/model/db"
+	"im-system/internal/module/usercache"
+	"im-system/internal/utils"
+	"strings"
+)
+
+const (
+	minPasswordLength = 6  // 密码最短长度
+	maxPasswordLength = 72 // 密码最长字节数，超出部分 bcrypt 会忽略
+)
+
+// validatePassword 检查密码长度
+func validatePassword(password string) error {
+	if len([]rune(password)) < minPasswordLength {
+		return errors.New("密码长度不能少于6位")
+	}
+	if len(password) > maxPasswordLength {
+		return errors.New("密码过长")
+	}
+	return nil
+}
+
+// ChangePassword 修改密码，需要验证旧密码，修改后所有设备需要重新登录
+func (s *UserService) ChangePassword(userID uint, oldPassword, newPassword string) error {
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+	var user db.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+	if !utils.ComparePassword(user.PasswordHash, oldPassword) {
+		return errors.New("旧密码错误")
+	}
+	if oldPassword == newPassword {
+		return errors.New("新密码不能与旧密码相同")
+	}
+	return s.setPassword(user.ID, newPassword)
+}
+
+// ResetPassword 忘记密码时通过发送到手机号或邮箱的验证码重置密码，重置后所有设备需要重新登录
+func (s *UserService) ResetPassword(target, code, newPassword string) error {
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+	target, _, err := normalizeVerifyTarget(target)
+	if err != nil {
+		return err
+	}
+	if err := s.verification.VerifyCode(VerifyResetPassword, target, code); err != nil {
+		return err
+	}
+	user, err := findUserByAccount(s.db, target)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+	if err := s.setPassword(user.ID, newPassword); err != nil {
+		return err
+	}
+	// 重置成功后解除该账号因登录失败产生的限制
+	clearLoginFailures(strings.ToLower(target))
+	return nil
+}
+
+// setPassword 保存新密码，并注销用户所有的登录会话
+func (s *UserService) setPassword(userID uint, password string) error {
+	hash, err := utils.HashPassword(password, config.Auth.BcryptCost)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Model(&db.User{}).Where("id = ?", userID).Update("password_hash", hash).Error; err != nil {
+		return err
+	}
+	usercache.Invalidate(userID)
+	return middle.RevokeAllSessions(userID)
+}
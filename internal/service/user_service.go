@@ -3,42 +3,80 @@ package service
 import (
 	"context"
 	"errors"
-	"fmt"
 	"im-system/internal/config"
 	"im-system/internal/middle"
 	"im-system/internal/model/db"
 	"im-system/internal/model/dto"
 	"im-system/internal/model/vo"
+	"im-system/internal/module/usercache"
 	"im-system/internal/utils"
+	"strings"
 	"time"
 
-	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type UserService struct {
-	db *gorm.DB
+	db           *gorm.DB
+	verification *VerificationService
 }
 
-func NewUserService() *UserService {
+func NewUserService(verification *VerificationService) *UserService {
 	return &UserService{
-		db: db.DB,
+		db:           db.DB,
+		verification: verification,
 	}
 }
 
-func (s *UserService) Register(userInfo db.User) error {
-	var existUser db.User
-	if err := s.db.Where("phone_number = ?", userInfo.PhoneNumber).First(&existUser).Error; err == nil {
-		return errors.New("手机号已经被注册")
+// Register 注册用户，手机号必须通过验证码验证，填写邮箱时邮箱也需要验证
+func (s *UserService) Register(registerDTO dto.RegisterDTO) error {
+	userInfo := registerDTO.User
+	userInfo.ID = 0
+	if !phonePattern.MatchString(userInfo.PhoneNumber) {
+		return errors.New("手机号格式不正确")
+	}
+	if err := validatePassword(userInfo.PasswordHash); err != nil {
+		return err
+	}
+	if err := checkAccountAvailable(s.db, "phone_number", userInfo.PhoneNumber, 0); err != nil {
+		return err
 	}
 
-	userInfo.PasswordHash = utils.HashPassword(userInfo.PasswordHash)
+	// 邮箱和账号名都是可选的，未填写时保存为 NULL，不再生成占位邮箱
+	var err error
+	if userInfo.Email, err = normalizeEmail(userInfo.Email); err != nil {
+		return err
+	}
+	if userInfo.Email != nil {
+		if err := checkAccountAvailable(s.db, "email", *userInfo.Email, 0); err != nil {
+			return err
+		}
+	}
+	if userInfo.Handle, err = normalizeHandle(userInfo.Handle); err != nil {
+		return err
+	}
+	if userInfo.Handle != nil {
+		if err := checkAccountAvailable(s.db, "handle", *userInfo.Handle, 0); err != nil {
+			return err
+		}
+	}
 
-	// 防止邮箱为空
-	if userInfo.Email == "" {
-		userInfo.Email = fmt.Sprintf("%s@imSystem.com", userInfo.Username)
+	// 格式和唯一性都检查通过后再校验验证码，避免验证码被无效请求消耗
+	if err := s.verification.VerifyCode(VerifyRegister, userInfo.PhoneNumber, registerDTO.Code); err != nil {
+		return err
+	}
+	if userInfo.Email != nil {
+		if err := s.verification.VerifyCode(VerifyRegister, *userInfo.Email, registerDTO.EmailCode); err != nil {
+			return err
+		}
 	}
 
+	hash, err := utils.HashPassword(userInfo.PasswordHash, config.Auth.BcryptCost)
+	if err != nil {
+		return err
+	}
+	userInfo.PasswordHash = hash
+
 	// 默认为 male
 	if userInfo.Gender == "" {
 		userInfo.Gender = "male"
@@ -55,8 +93,8 @@ func (s *UserService) Register(userInfo db.User) error {
 
 	// 创建默认的好友分组
 	defaultGroup := db.FriendGroup{
-		UserID:    userInfo.ID, // 使用新注册用户的ID
-		GroupName: "我的好友",      // 默认分组名称
+		UserID:    userInfo.ID,      // 使用新注册用户的ID
+		GroupName: DefaultGroupName, // 默认分组名称
 	}
 
 	if err := s.db.Create(&defaultGroup).Error; err != nil {
@@ -67,29 +105,72 @@ func (s *UserService) Register(userInfo db.User) error {
 	return nil
 }
 
-func (s *UserService) Login(phoneNumber, password string) (uint, string, error) {
-	var user db.User
-	if err := s.db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
-		return 0, "", errors.New("用户不存在")
+// Login 用户登录，账号可以是手机号、邮箱或账号名，为当前设备创建一个新的会话。
+// 用户不存在和密码错误返回相同的错误，连续失败时按账号和IP限流并临时锁定
+func (s *UserService) Login(loginDTO dto.LoginDTO, ip string) (vo.TokenVO, error) {
+	account := strings.TrimSpace(loginDTO.Account)
+	if account == "" {
+		account = strings.TrimSpace(loginDTO.PhoneNumber)
 	}
-
-	if !utils.ComparePassword(user.PasswordHash, password) {
-		return 0, "", errors.New("密码错误")
+	// 邮箱和账号名不区分大小写，统一小写后再限流，避免通过大小写变化绕过
+	account = strings.ToLower(account)
+	if account == "" {
+		return vo.TokenVO{}, errLoginFailed
+	}
+	if err := checkLoginAllowed(account, ip); err != nil {
+		return vo.TokenVO{}, err
 	}
 
-	// 生成 JWT
-	token, err := middle.GenerateJWT(user.ID)
+	user, err := findUserByAccount(s.db, account)
 	if err != nil {
-		return 0, "", err
+		compareDummyPassword(loginDTO.Password)
+		recordLoginFailure(account, ip)
+		recordLoginAudit(s.db, 0, account, ip, loginFailUserNotFound)
+		return vo.TokenVO{}, errLoginFailed
 	}
 
-	// 将 token 和用户信息存储到 Redis
-	if err := middle.SetTokenToRedis(user.ID, user); err != nil {
-		return 0, "", err
+	if !utils.ComparePassword(user.PasswordHash, loginDTO.Password) {
+		recordLoginFailure(account, ip)
+		recordLoginAudit(s.db, user.ID, account, ip, loginFailWrongPassword)
+		return vo.TokenVO{}, errLoginFailed
 	}
 
-	// 返回用户ID和token
-	return user.ID, token, nil
+	// 旧版 SHA-256 哈希或 bcrypt cost 变更时，登录成功后原地升级哈希
+	if utils.NeedsRehash(user.PasswordHash, config.Auth.BcryptCost) {
+		s.rehashPassword(&user, loginDTO.Password)
+	}
+
+	// 开启了两步验证时先返回挑战令牌，通过 LoginTwoFactor 完成登录，第二步通过后才清除失败计数
+	if user.TOTPEnabled {
+		return createTwoFactorChallenge(user, account, loginDTO.DeviceName, ip)
+	}
+	clearLoginFailures(account)
+
+	// 创建会话并签发访问令牌和刷新令牌
+	tokens, err := middle.CreateSession(user, loginDTO.DeviceName, ip)
+	if err != nil {
+		return vo.TokenVO{}, err
+	}
+	return toTokenVO(tokens), nil
+}
+
+// rehashPassword 使用当前配置重新生成密码哈希，失败时仅记录日志，不影响本次登录
+func (s *UserService) rehashPassword(user *db.User, password string) {
+	hash, err := utils.HashPassword(password, config.Auth.BcryptCost)
+	if err != nil {
+		config.Logger.Error(err)
+		return
+	}
+	// 条件更新，避免覆盖并发修改过的密码
+	result := s.db.Model(&db.User{}).
+		Where("id = ? AND password_hash = ?", user.ID, user.PasswordHash).
+		Update("password_hash", hash)
+	if result.Error != nil {
+		config.Logger.Error(result.Error)
+		return
+	}
+	user.PasswordHash = hash
+	usercache.Invalidate(user.ID)
 }
 
 // GetUserInfo 获取用户信息
@@ -102,7 +183,9 @@ func (s *UserService) GetUserInfo(userID uint) (vo.UserVO, error) {
 	return vo.UserVO{
 		ID:          user.ID,
 		Username:    user.Username,
-		Email:       user.Email,
+		Email:       stringValue(user.Email),
+		Handle:      stringValue(user.Handle),
+		TwoFactor:   user.TOTPEnabled,
 		PhoneNumber: user.PhoneNumber,
 		AvatarURL:   user.AvatarURL,
 		Bio:         user.Bio,
@@ -113,58 +196,110 @@ func (s *UserService) GetUserInfo(userID uint) (vo.UserVO, error) {
 	}, nil
 }
 
-// AddFriend 添加好友,todo: 事务完善
-func (s *UserService) AddFriend(addFriendDto dto.AddFriendDTO) error {
+// AddFriend 发送好友请求，被拒绝或过期后再次请求会复用原来的记录。
+// 对方已经向自己发送了待处理的请求时，直接互相添加为好友，返回 true
+func (s *UserService) AddFriend(addFriendDto dto.AddFriendDTO) (bool, error) {
+	if addFriendDto.UserID == addFriendDto.FriendID {
+		return false, errors.New("不能添加自己为好友")
+	}
+
 	var friend db.User
 	// 1.查询好友是否存在
 	if err := s.db.First(&friend, addFriendDto.FriendID).Error; err != nil {
-		return errors.New("好友不存在")
+		return false, errors.New("好友不存在")
 	}
 
-	// 查询分组是否存在
+	// 查询分组是否存在，分组必须属于请求者
 	var group db.FriendGroup
-	if err := s.db.First(&group, addFriendDto.GroupID).Error; err != nil {
-		return errors.New("分组不存在")
+	if err := s.db.Where("id = ? AND user_id = ?", addFriendDto.GroupID, addFriendDto.UserID).First(&group).Error; err != nil {
+		return false, errors.New("分组不存在")
 	}
 
-	// 2.创建好友关系，不创建关系表，直接创建通知
-	friendship := db.Friendship{
-		UserID:   addFriendDto.UserID,
-		FriendID: addFriendDto.FriendID,
-		Remark:   addFriendDto.Remark,
-		GroupID:  addFriendDto.GroupID,
-		Status:   "pending", // 发送中
-	}
+	var notification db.Notification
+	autoAccepted := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 2.已有好友关系时根据状态判断是否可以重新发送
+		friendship, err := lockFriendship(tx, addFriendDto.UserID, addFriendDto.FriendID)
+		if err != nil {
+			return err
+		}
+		switch friendship.Status {
+		case FriendshipAccepted:
+			return errors.New("对方已经是您的好友")
+		case FriendshipBlocked:
+			return errors.New("您已拉黑对方，请先取消拉黑")
+		case FriendshipPending:
+			if !friendRequestExpired(friendship.UpdatedAt) {
+				return errors.New("好友请求已发送，请等待对方处理")
+			}
+		}
+		friendship.Remark = addFriendDto.Remark
+		friendship.GroupID = addFriendDto.GroupID
 
-	if err := s.db.Create(&friendship).Error; err != nil {
-		return err
-	}
+		reverse, err := lockFriendship(tx, addFriendDto.FriendID, addFriendDto.UserID)
+		if err != nil {
+			return err
+		}
+		if reverse.Status == FriendshipBlocked {
+			return errors.New("对方拒绝接收您的好友请求")
+		}
+		// 对方也向自己发送了待处理的请求，直接互相添加为好友
+		if reverse.Status == FriendshipPending && !friendRequestExpired(reverse.UpdatedAt) {
+			autoAccepted = true
+			return acceptMutualRequest(tx, &friendship, &reverse, &notification)
+		}
 
-	// 3.创建通知。发送通知
-	notification := db.Notification{
-		SenderID:   addFriendDto.UserID,
-		ReceiverID: addFriendDto.FriendID,
-		Type:       "friend_request",
-		Content:    addFriendDto.Content,
-	}
+		// 限制发送好友请求的频率，校验通过、确实要发出请求时才计数
+		if err := checkFriendRequestRate(addFriendDto.UserID); err != nil {
+			return err
+		}
 
-	if err := s.db.Create(&notification).Error; err != nil {
+		// 创建或重新发送好友关系
+		if err := transitFriendship(tx, &friendship, FriendshipPending); err != nil {
+			return err
+		}
+
+		// 3.创建通知。被拒绝或过期的请求复用原来的通知
+		err = tx.Where("sender_id = ? AND receiver_id = ? AND type = ?", addFriendDto.UserID, addFriendDto.FriendID, "friend_request").
+			Order("id desc").First(&notification).Error
+		if err == nil {
+			return tx.Model(&notification).Updates(map[string]interface{}{
+				"status":     "pending",
+				"is_read":    false,
+				"content":    addFriendDto.Content,
+				"created_at": time.Now(),
+			}).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		notification = db.Notification{
+			SenderID:   addFriendDto.UserID,
+			ReceiverID: addFriendDto.FriendID,
+			Type:       "friend_request",
+			Content:    addFriendDto.Content,
+		}
+		return tx.Create(&notification).Error
+	})
+	if err != nil {
 		config.Logger.Error(err)
-		return err
+		return false, err
 	}
 
-	return nil
+	// 实时推送给对方
+	if autoAccepted {
+		if notification.ID != 0 {
+			pushNotificationUpdate(s.db, notification)
+		}
+	} else {
+		pushNotification(s.db, notification)
+	}
+	return autoAccepted, nil
 }
 
-// Logout 处理用户退出登录
-func (s *UserService) Logout(c *gin.Context, userId uint) error {
-	// 使用 pipeline 删除 Redis 中的用户信息和 JWT token
-	pipe := config.RedisClient.Pipeline()
-	pipe.Del(c, middle.GetRedisUserInfoKey(userId))
-	pipe.Del(c, middle.GetRedisJWTKey(userId))
-
-	_, err := pipe.Exec(c)
-	if err != nil {
+// Logout 处理用户退出登录，只注销当前设备的会话
+func (s *UserService) Logout(userId uint, sessionID string) error {
+	if err := middle.RevokeSession(userId, sessionID); err != nil {
 		config.Logger.Error(err)
 		return err
 	}
@@ -174,29 +309,49 @@ func (s *UserService) Logout(c *gin.Context, userId uint) error {
 // UpdateUserInfo 更新用户信息
 func (s *UserService) UpdateUserInfo(userID uint, updateData db.User) error {
 	var user db.User
-	if err := s.db.First(&user, userID).Error; err != nil {
+	if err := s.db.Select("id").First(&user, userID).Error; err != nil {
 		return err
 	}
-	// 更新用户信息
+	// 只更新本次修改的资料字段，避免覆盖并发修改的密码、两步验证、角色、手机号和邮箱
+	updates := make(map[string]interface{})
 	if updateData.Username != "" {
-		user.Username = updateData.Username
+		updates["username"] = updateData.Username
 	}
 	if updateData.City != "" {
-		user.City = updateData.City
+		updates["city"] = updateData.City
 	}
 	if updateData.AvatarURL != "" {
-		user.AvatarURL = updateData.AvatarURL
+		updates["avatar_url"] = updateData.AvatarURL
 	}
 	if updateData.Bio != "" {
-		user.Bio = updateData.Bio
+		updates["bio"] = updateData.Bio
 	}
 	if updateData.Gender != "" {
-		user.Gender = updateData.Gender
+		updates["gender"] = updateData.Gender
 	}
 	if updateData.DateOfBirth != "" {
-		user.DateOfBirth = updateData.DateOfBirth
+		updates["date_of_birth"] = updateData.DateOfBirth
+	}
+	if updateData.Handle != nil {
+		handle, err := normalizeHandle(updateData.Handle)
+		if err != nil {
+			return err
+		}
+		if handle != nil {
+			if err := checkAccountAvailable(s.db, "handle", *handle, userID); err != nil {
+				return err
+			}
+			updates["handle"] = *handle
+		}
+	}
+	if len(updates) == 0 {
+		return nil
 	}
-	return s.db.Save(&user).Error
+	if err := s.db.Model(&db.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+		return err
+	}
+	usercache.Invalidate(userID)
+	return nil
 }
 
 // QueryUserAndGroup 查询用户和群聊信息
@@ -224,7 +379,8 @@ func (s *UserService) QueryUserAndGroup(dto dto.QueryUserAndGroupDTO) (vo.UserAn
 		userVOs = append(userVOs, vo.UserVO{
 			ID:          user.ID,
 			Username:    user.Username,
-			Email:       user.Email,
+			Email:       stringValue(user.Email),
+			Handle:      stringValue(user.Handle),
 			PhoneNumber: user.PhoneNumber,
 			AvatarURL:   user.AvatarURL,
 			Bio:         user.Bio,
@@ -252,47 +408,99 @@ func (s *UserService) QueryUserAndGroup(dto dto.QueryUserAndGroupDTO) (vo.UserAn
 	}, nil
 }
 
-// DeleteFriend 删除好友
+// DeleteFriend 删除好友，双方的好友关系在同一个事务中标记为已删除
 func (s *UserService) DeleteFriend(userID, friendID uint) error {
-	// 检查好友关系是否存在
-	var friendRelation db.Friendship
-	if err := s.db.Where("user_id = ? AND friend_id = ?", userID, friendID).First(&friendRelation).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// 检查好友关系是否存在
+		friendRelation, err := lockFriendship(tx, userID, friendID)
+		if err != nil {
+			return err
+		}
+		if friendRelation.Status != FriendshipAccepted {
 			return errors.New("好友关系不存在")
 		}
-		return err
-	}
+		if err := transitFriendship(tx, &friendRelation, FriendshipDeleted); err != nil {
+			return err
+		}
 
-	// 删除好友关系
-	if err := s.db.Delete(&friendRelation).Error; err != nil {
-		config.Logger.Error(err)
-		return errors.New("删除好友失败")
+		// 删除对方的好友关系
+		reverseFriendRelation, err := lockFriendship(tx, friendID, userID)
+		if err != nil {
+			return err
+		}
+		if reverseFriendRelation.Status == FriendshipAccepted {
+			return transitFriendship(tx, &reverseFriendRelation, FriendshipDeleted)
+		}
+		return nil
+	})
+}
+
+// BlockUser 拉黑用户，双方已有的好友关系和对方的待处理请求都会失效
+func (s *UserService) BlockUser(userID, targetID uint) error {
+	if userID == targetID {
+		return errors.New("不能拉黑自己")
+	}
+	var target db.User
+	if err := s.db.First(&target, targetID).Error; err != nil {
+		return errors.New("用户不存在")
 	}
 
-	// 删除对方的好友关系
-	var reverseFriendRelation db.Friendship
-	if err := s.db.Where("user_id = ? AND friend_id = ?", friendID, userID).First(&reverseFriendRelation).Error; err == nil {
-		if err := s.db.Delete(&reverseFriendRelation).Error; err != nil {
-			config.Logger.Error(err)
-			return errors.New("删除好友失败")
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		friendship, err := lockFriendship(tx, userID, targetID)
+		if err != nil {
+			return err
+		}
+		if friendship.Status == FriendshipBlocked {
+			return nil
+		}
+		if friendship.Remark == "" {
+			friendship.Remark = target.Username
+		}
+		if err := transitFriendship(tx, &friendship, FriendshipBlocked); err != nil {
+			return err
 		}
-	}
 
-	return nil
+		reverse, err := lockFriendship(tx, targetID, userID)
+		if err != nil {
+			return err
+		}
+		if reverse.Status == FriendshipAccepted || reverse.Status == FriendshipPending {
+			return transitFriendship(tx, &reverse, FriendshipDeleted)
+		}
+		return nil
+	})
+}
+
+// UnblockUser 取消拉黑，取消后需要重新添加好友
+func (s *UserService) UnblockUser(userID, targetID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		friendship, err := lockFriendship(tx, userID, targetID)
+		if err != nil {
+			return err
+		}
+		if friendship.Status != FriendshipBlocked {
+			return errors.New("您没有拉黑该用户")
+		}
+		return transitFriendship(tx, &friendship, FriendshipDeleted)
+	})
 }
 
 // UpdateUserAvatar 更新用户头像
 func (s *UserService) UpdateUserAvatar(userID uint, avatarURL string) error {
-	return s.db.Model(&db.User{}).Where("id = ?", userID).Update("avatar_url", avatarURL).Error
+	if err := s.db.Model(&db.User{}).Where("id = ?", userID).Update("avatar_url", avatarURL).Error; err != nil {
+		return err
+	}
+	usercache.Invalidate(userID)
+	return nil
 }
 
-// CheckToken 检查 token 是否存在
-func (s *UserService) CheckToken(userID uint) (bool, error) {
+// CheckToken 检查 token 所属的会话是否存在
+func (s *UserService) CheckToken(userID uint, sessionID string) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// 检查 Redis 中是否存在该用户的 JWT token
-	exists, err := config.RedisClient.Exists(ctx, middle.GetRedisJWTKey(userID)).Result()
+	// 检查 Redis 中是否存在该会话
+	exists, err := config.RedisClient.Exists(ctx, middle.GetRedisSessionKey(userID, sessionID)).Result()
 	if err != nil {
 		return false, err
 	}
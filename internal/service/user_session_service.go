@@ -0,0 +1,60 @@
+package service
+
+import (
+	"errors"
+	"im-system/internal/middle"
+	"im-system/internal/model/vo"
+)
+
+// RefreshToken 使用刷新令牌换取新的访问令牌和刷新令牌
+func (s *UserService) RefreshToken(refreshToken, ip string) (vo.TokenVO, error) {
+	tokens, err := middle.RefreshSession(refreshToken, ip)
+	if err != nil {
+		return vo.TokenVO{}, err
+	}
+	return toTokenVO(tokens), nil
+}
+
+// GetSessions 获取用户所有设备上的会话
+func (s *UserService) GetSessions(userID uint, currentSessionID string) ([]vo.SessionVO, error) {
+	sessions, err := middle.ListSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]vo.SessionVO, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, vo.SessionVO{
+			ID:           session.ID,
+			DeviceName:   session.DeviceName,
+			IP:           session.IP,
+			CreatedAt:    session.CreatedAt,
+			LastActiveAt: session.LastActiveAt,
+			Current:      session.ID == currentSessionID,
+		})
+	}
+	return result, nil
+}
+
+// RevokeSession 注销用户的某个会话，用于下线指定设备
+func (s *UserService) RevokeSession(userID uint, sessionID string) error {
+	if _, err := middle.GetSession(userID, sessionID); err != nil {
+		return errors.New("会话不存在")
+	}
+	return middle.RevokeSession(userID, sessionID)
+}
+
+// RevokeAllSessions 注销用户的所有会话，包括当前会话
+func (s *UserService) RevokeAllSessions(userID uint) error {
+	return middle.RevokeAllSessions(userID)
+}
+
+// toTokenVO 将会话令牌转换为返回给客户端的视图对象
+func toTokenVO(tokens middle.TokenPair) vo.TokenVO {
+	return vo.TokenVO{
+		UserID:       tokens.UserID,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		SessionID:    tokens.SessionID,
+	}
+}
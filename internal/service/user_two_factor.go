@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"im-system/internal/config"
+	"im-system/internal/middle"
+	"im-system/internal/model/db"
+	"im-system/internal/model/vo"
+	"im-system/internal/module/usercache"
+	"im-system/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// recoveryCodeCount 每次生成的恢复码数量
+const recoveryCodeCount = 10
+
+// errRecoveryCodeInvalid 恢复码错误或已经使用过
+var errRecoveryCodeInvalid = errors.New("验证码错误")
+
+// GetRedisTwoFactorChallengeKey 获取两步验证登录挑战的 Redis key
+func GetRedisTwoFactorChallengeKey(token string) string {
+	return fmt.Sprintf("login:2fa:challenge:%s", token)
+}
+
+// GetRedisTOTPUsedKey 获取已使用过的 TOTP 时间步的 Redis key，防止验证码被重放
+func GetRedisTOTPUsedKey(userID uint, step int64) string {
+	return fmt.Sprintf("login:2fa:used:%d:%d", userID, step)
+}
+
+// EnrollTwoFactor 开始开启两步验证，生成新的密钥，需要调用 ConfirmTwoFactor 确认后才生效
+func (s *UserService) EnrollTwoFactor(userID uint) (vo.TwoFactorEnrollVO, error) {
+	var user db.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return vo.TwoFactorEnrollVO{}, errors.New("用户不存在")
+	}
+	if user.TOTPEnabled {
+		return vo.TwoFactorEnrollVO{}, errors.New("已经开启了两步验证")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return vo.TwoFactorEnrollVO{}, err
+	}
+	if err := s.db.Model(&db.User{}).Where("id = ? AND totp_enabled = ?", userID, false).
+		Update("totp_secret", secret).Error; err != nil {
+		return vo.TwoFactorEnrollVO{}, err
+	}
+	usercache.Invalidate(userID)
+
+	account := stringValue(user.Handle)
+	if account == "" {
+		account = user.PhoneNumber
+	}
+	return vo.TwoFactorEnrollVO{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(secret, config.Auth.TOTPIssuer, account),
+	}, nil
+}
+
+// ConfirmTwoFactor 使用身份验证器生成的验证码确认开启两步验证，返回只展示一次的恢复码
+func (s *UserService) ConfirmTwoFactor(userID uint, code string) ([]string, error) {
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var user db.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return errors.New("用户不存在")
+		}
+		if user.TOTPEnabled {
+			return errors.New("已经开启了两步验证")
+		}
+		if user.TOTPSecret == "" {
+			return errors.New("请先获取两步验证密钥")
+		}
+		if err := checkTOTP(user, code); err != nil {
+			return err
+		}
+		if err := tx.Model(&db.User{}).Where("id = ?", userID).Update("totp_enabled", true).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err == nil {
+		usercache.Invalidate(userID)
+	}
+	return codes, err
+}
+
+// DisableTwoFactor 关闭两步验证，需要同时提供密码和验证码（或恢复码）
+func (s *UserService) DisableTwoFactor(userID uint, password, code string) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var user db.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return errors.New("用户不存在")
+		}
+		if !user.TOTPEnabled {
+			return errors.New("未开启两步验证")
+		}
+		if !utils.ComparePassword(user.PasswordHash, password) {
+			return errors.New("密码错误")
+		}
+		if err := verifySecondFactor(tx, user, code); err != nil {
+			return err
+		}
+		if err := tx.Model(&db.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{"totp_enabled": false, "totp_secret": ""}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&db.UserRecoveryCode{}).Error
+	})
+	if err == nil {
+		usercache.Invalidate(userID)
+	}
+	return err
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧的恢复码全部作废
+func (s *UserService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var user db.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return errors.New("用户不存在")
+		}
+		if !user.TOTPEnabled {
+			return errors.New("未开启两步验证")
+		}
+		if err := checkTOTP(user, code); err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+// LoginTwoFactor 登录第二步：校验挑战令牌和验证码（或恢复码），通过后创建会话。
+// 验证码错误与密码错误一样计入账号和IP的登录失败次数，避免通过反复登录获取新的挑战来暴力破解
+func (s *UserService) LoginTwoFactor(challengeToken, code, ip string) (vo.TokenVO, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	key := GetRedisTwoFactorChallengeKey(challengeToken)
+	challenge, err := config.RedisClient.HGetAll(ctx, key).Result()
+	if err != nil {
+		return vo.TokenVO{}, err
+	}
+	if len(challenge) == 0 {
+		return vo.TokenVO{}, errors.New("验证已过期，请重新登录")
+	}
+	account := challenge["account"]
+	if account == "" {
+		return vo.TokenVO{}, errors.New("验证已过期，请重新登录")
+	}
+	if err := checkLoginAllowed(account, ip); err != nil {
+		return vo.TokenVO{}, err
+	}
+	attempts, err := config.RedisClient.HIncrBy(ctx, key, "attempts", 1).Result()
+	if err != nil {
+		return vo.TokenVO{}, err
+	}
+	if attempts > int64(config.Auth.TwoFactorMaxAttempts) {
+		if err := config.RedisClient.Del(ctx, key).Err(); err != nil {
+			config.Logger.Error(err)
+		}
+		return vo.TokenVO{}, &TooManyRequestsError{Message: "验证码错误次数过多，请重新登录"}
+	}
+
+	userID, err := strconv.ParseUint(challenge["user_id"], 10, 32)
+	if err != nil {
+		return vo.TokenVO{}, errors.New("验证已过期，请重新登录")
+	}
+	var user db.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return vo.TokenVO{}, errors.New("用户不存在")
+	}
+	if err := verifySecondFactor(s.db, user, code); err != nil {
+		if isSecondFactorRejected(err) {
+			recordLoginFailure(account, ip)
+			recordLoginAudit(s.db, user.ID, account, ip, loginFailWrongCode)
+		}
+		return vo.TokenVO{}, err
+	}
+
+	// 挑战令牌只能使用一次，并发请求中只有成功删除的一方可以登录
+	deleted, err := config.RedisClient.Del(ctx, key).Result()
+	if err != nil {
+		return vo.TokenVO{}, err
+	}
+	if deleted == 0 {
+		return vo.TokenVO{}, errors.New("验证已过期，请重新登录")
+	}
+	clearLoginFailures(account)
+
+	tokens, err := middle.CreateSession(user, challenge["device_name"], challenge["ip"])
+	if err != nil {
+		return vo.TokenVO{}, err
+	}
+	return toTokenVO(tokens), nil
+}
+
+// createTwoFactorChallenge 密码验证通过后创建短期有效的挑战令牌，第二步登录时使用，account 为登录失败计数使用的账号
+func createTwoFactorChallenge(user db.User, account, deviceName, ip string) (vo.TokenVO, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return vo.TokenVO{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	key := GetRedisTwoFactorChallengeKey(token)
+	pipe := config.RedisClient.TxPipeline()
+	pipe.HSet(ctx, key, "user_id", user.ID, "account", account, "device_name", deviceName, "ip", ip, "attempts", 0)
+	pipe.Expire(ctx, key, time.Duration(config.Auth.TwoFactorChallengeTTL)*time.Second)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return vo.TokenVO{}, err
+	}
+	return vo.TokenVO{
+		UserID:            user.ID,
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresIn:         config.Auth.TwoFactorChallengeTTL,
+	}, nil
+}
+
+// verifySecondFactor 校验两步验证码，6 位数字按 TOTP 校验，其余按恢复码校验
+func verifySecondFactor(tx *gorm.DB, user db.User, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == 6 && strings.Trim(code, "0123456789") == "" {
+		return checkTOTP(user, code)
+	}
+	return useRecoveryCode(tx, user.ID, code)
+}
+
+// isSecondFactorRejected 判断错误是否为验证码或恢复码不正确，Redis 和数据库错误不计入登录失败次数
+func isSecondFactorRejected(err error) bool {
+	return errors.Is(err, utils.ErrTOTPInvalid) || errors.Is(err, utils.ErrTOTPReused) || errors.Is(err, errRecoveryCodeInvalid)
+}
+
+// checkTOTP 校验 TOTP 验证码，同一个时间步的验证码只能使用一次
+func checkTOTP(user db.User, code string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return utils.ValidateTOTPOnce(user.TOTPSecret, strings.TrimSpace(code), time.Now(), func(step int64) (bool, error) {
+		return config.RedisClient.SetNX(ctx, GetRedisTOTPUsedKey(user.ID, step), 1, 3*time.Minute).Result()
+	})
+}
+
+// useRecoveryCode 使用一个恢复码，使用后立即作废
+func useRecoveryCode(tx *gorm.DB, userID uint, code string) error {
+	result := tx.Model(&db.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errRecoveryCodeInvalid
+	}
+	return nil
+}
+
+// replaceRecoveryCodes 删除用户旧的恢复码并生成新的，返回明文恢复码
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&db.UserRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]db.UserRecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, db.UserRecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(code)})
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode 生成形如 abcd-efgh 的恢复码
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 5)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(buf))
+	return code[:4] + "-" + code[4:], nil
+}
+
+// hashRecoveryCode 恢复码只保存哈希，比较前忽略大小写和连字符
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
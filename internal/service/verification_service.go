@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"im-system/internal/config"
+	"im-system/internal/model/db"
+	"im-system/internal/module/sender"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 验证码用途，不同用途的验证码互不通用
+const (
+	VerifyRegister      = "register"       // 注册
+	VerifyResetPassword = "reset_password" // 重置密码
+	VerifyChangePhone   = "change_phone"   // 修改手机号
+	VerifyChangeEmail   = "change_email"   // 修改邮箱
+)
+
+// verifyPurposeNames 验证码用途的中文名称，用于消息内容
+var verifyPurposeNames = map[string]string{
+	VerifyRegister:      "注册账号",
+	VerifyResetPassword: "重置密码",
+	VerifyChangePhone:   "修改手机号",
+	VerifyChangeEmail:   "修改邮箱",
+}
+
+type VerificationService struct {
+	db          *gorm.DB
+	emailSender sender.Sender
+	smsSender   sender.Sender
+}
+
+func NewVerificationService() *VerificationService {
+	return &VerificationService{
+		db:          db.DB,
+		emailSender: newEmailSender(),
+		// 尚未接入短信服务商，短信验证码统一写入日志文件
+		smsSender: sender.NewLogSender(config.Verify.LogFile),
+	}
+}
+
+// newEmailSender 根据配置创建邮件发送渠道
+func newEmailSender() sender.Sender {
+	if config.Verify.EmailSender == "smtp" {
+		smtp := config.Verify.SMTP
+		return sender.NewSMTPSender(smtp.Host, smtp.Port, smtp.Username, smtp.Password, smtp.From)
+	}
+	return sender.NewLogSender(config.Verify.LogFile)
+}
+
+// GetRedisVerifyCodeKey 获取验证码的 Redis key
+func GetRedisVerifyCodeKey(purpose, target string) string {
+	return fmt.Sprintf("verify:code:%s:%s", purpose, target)
+}
+
+// GetRedisVerifyThrottleKey 获取验证码重发间隔的 Redis key
+func GetRedisVerifyThrottleKey(purpose, target string) string {
+	return fmt.Sprintf("verify:throttle:%s:%s", purpose, target)
+}
+
+// GetRedisVerifyIPCountKey 获取同一IP发送验证码计数的 Redis key
+func GetRedisVerifyIPCountKey(ip string) string {
+	return fmt.Sprintf("verify:count:ip:%s", ip)
+}
+
+// GetRedisVerifyDailyCountKey 获取同一手机号或邮箱每天发送验证码计数的 Redis key
+func GetRedisVerifyDailyCountKey(target string) string {
+	return fmt.Sprintf("verify:count:daily:%s", target)
+}
+
+// SendCode 向手机号或邮箱发送验证码，userID 为当前登录用户，修改手机号和邮箱时必须提供，ip 为请求方IP
+func (s *VerificationService) SendCode(purpose, target string, userID uint, ip string) error {
+	if _, ok := verifyPurposeNames[purpose]; !ok {
+		return errors.New("不支持的验证码用途")
+	}
+	target, column, err := normalizeVerifyTarget(target)
+	if err != nil {
+		return err
+	}
+
+	if purpose == VerifyChangePhone || purpose == VerifyChangeEmail {
+		if userID == 0 {
+			return errors.New("用户未登录")
+		}
+		if (purpose == VerifyChangePhone) != (column == "phone_number") {
+			return errors.New("验证码用途与接收方式不匹配")
+		}
+		if err := checkAccountAvailable(s.db, column, target, userID); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 先限流再检查账号是否存在，账号存在与否不影响限流结果
+	ipWindow := time.Duration(config.Verify.IPWindow) * time.Second
+	if incrCounter(ctx, GetRedisVerifyIPCountKey(ip), ipWindow) > int64(config.Verify.IPMaxSends) {
+		return &TooManyRequestsError{Message: "验证码发送过于频繁，请稍后再试"}
+	}
+	throttleKey := GetRedisVerifyThrottleKey(purpose, target)
+	interval := time.Duration(config.Verify.ResendInterval) * time.Second
+	ok, err := config.RedisClient.SetNX(ctx, throttleKey, 1, interval).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &TooManyRequestsError{Message: "验证码发送过于频繁，请稍后再试"}
+	}
+	if incrCounter(ctx, GetRedisVerifyDailyCountKey(target), 24*time.Hour) > int64(config.Verify.DailyMaxSends) {
+		return &TooManyRequestsError{Message: "今天获取验证码的次数已达上限，请明天再试"}
+	}
+
+	// 注册时账号已存在、重置密码时账号不存在都不发送，但同样返回成功，避免被用来探测账号是否注册
+	switch purpose {
+	case VerifyRegister:
+		var count int64
+		if err := s.db.Model(&db.User{}).Where(column+" = ?", target).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+	case VerifyResetPassword:
+		if _, err := findUserByAccount(s.db, target); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	code, err := generateVerifyCode(config.Verify.CodeLength)
+	if err != nil {
+		return err
+	}
+	codeKey := GetRedisVerifyCodeKey(purpose, target)
+	ttl := time.Duration(config.Verify.CodeTTL) * time.Second
+	pipe := config.RedisClient.TxPipeline()
+	pipe.Del(ctx, codeKey)
+	pipe.HSet(ctx, codeKey, "hash", hashVerifyCode(purpose, target, code), "attempts", 0)
+	pipe.Expire(ctx, codeKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("【IM】%s验证码", verifyPurposeNames[purpose])
+	content := fmt.Sprintf("您正在%s，验证码为 %s，%d 分钟内有效。如非本人操作请忽略。",
+		verifyPurposeNames[purpose], code, config.Verify.CodeTTL/60)
+	channel := s.smsSender
+	if column == "email" {
+		channel = s.emailSender
+	}
+	if err := channel.Send(target, subject, content); err != nil {
+		config.Logger.Error(err)
+		if err := config.RedisClient.Del(ctx, codeKey, throttleKey).Err(); err != nil {
+			config.Logger.Error(err)
+		}
+		return errors.New("验证码发送失败，请稍后再试")
+	}
+	return nil
+}
+
+// VerifyCode 校验验证码，校验成功后验证码立即作废，错误次数过多时同样作废
+func (s *VerificationService) VerifyCode(purpose, target, code string) error {
+	target, _, err := normalizeVerifyTarget(target)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	codeKey := GetRedisVerifyCodeKey(purpose, target)
+	stored, err := config.RedisClient.HGet(ctx, codeKey, "hash").Result()
+	if err != nil {
+		return errors.New("验证码已过期，请重新获取")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashVerifyCode(purpose, target, strings.TrimSpace(code))), []byte(stored)) != 1 {
+		attempts, err := config.RedisClient.HIncrBy(ctx, codeKey, "attempts", 1).Result()
+		if err != nil {
+			return err
+		}
+		if attempts >= int64(config.Verify.MaxAttempts) {
+			if err := config.RedisClient.Del(ctx, codeKey).Err(); err != nil {
+				config.Logger.Error(err)
+			}
+			return errors.New("验证码错误次数过多，请重新获取")
+		}
+		return errors.New("验证码错误")
+	}
+
+	// 并发校验同一个验证码时，只有成功删除的请求算作通过
+	deleted, err := config.RedisClient.Del(ctx, codeKey).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return errors.New("验证码已过期，请重新获取")
+	}
+	return nil
+}
+
+// normalizeVerifyTarget 规范化验证码接收方，返回规范化后的手机号或邮箱以及对应的列名
+func normalizeVerifyTarget(target string) (string, string, error) {
+	target = strings.TrimSpace(target)
+	if strings.Contains(target, "@") {
+		email, err := normalizeEmail(&target)
+		if err != nil {
+			return "", "", err
+		}
+		return *email, "email", nil
+	}
+	if !phonePattern.MatchString(target) {
+		return "", "", errors.New("手机号格式不正确")
+	}
+	return target, "phone_number", nil
+}
+
+// generateVerifyCode 生成指定长度的数字验证码
+func generateVerifyCode(length int) (string, error) {
+	var code strings.Builder
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code.WriteByte(byte('0' + n.Int64()))
+	}
+	return code.String(), nil
+}
+
+// hashVerifyCode Redis 中只保存验证码的哈希
+func hashVerifyCode(purpose, target, code string) string {
+	sum := sha256.Sum256([]byte(purpose + ":" + target + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
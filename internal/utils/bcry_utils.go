@@ -2,17 +2,58 @@ package utils
 
 import (
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword 使用 SHA-256 对密码进行加密
-func HashPassword(password string) string {
-	hash := sha256.New()
-	hash.Write([]byte(password))
-	return hex.EncodeToString(hash.Sum(nil))
+// legacyHashLength 旧版 SHA-256 哈希的十六进制长度
+const legacyHashLength = sha256.Size * 2
+
+// HashPassword 使用 bcrypt 对密码进行加密，cost 超出 bcrypt 允许范围时使用默认值
+func HashPassword(password string, cost int) (string, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
 }
 
-// ComparePassword 对比输入的密码和哈希值
+// ComparePassword 对比输入的密码和哈希值，同时兼容旧版的 SHA-256 哈希
 func ComparePassword(hashedPassword, password string) bool {
-	return HashPassword(password) == hashedPassword
+	if IsLegacyHash(hashedPassword) {
+		return subtle.ConstantTimeCompare([]byte(legacyHashPassword(password)), []byte(hashedPassword)) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)) == nil
+}
+
+// NeedsRehash 判断哈希值是否需要重新生成：旧版 SHA-256 哈希或 bcrypt cost 与当前配置不一致
+func NeedsRehash(hashedPassword string, cost int) bool {
+	if IsLegacyHash(hashedPassword) {
+		return true
+	}
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	current, err := bcrypt.Cost([]byte(hashedPassword))
+	return err != nil || current != cost
+}
+
+// IsLegacyHash 判断是否为旧版无盐 SHA-256 哈希
+func IsLegacyHash(hashedPassword string) bool {
+	if len(hashedPassword) != legacyHashLength {
+		return false
+	}
+	_, err := hex.DecodeString(hashedPassword)
+	return err == nil
+}
+
+// legacyHashPassword 旧版的 SHA-256 密码哈希，仅用于校验迁移前的密码
+func legacyHashPassword(password string) string {
+	hash := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(hash[:])
 }
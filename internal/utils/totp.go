@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	totpDigits     = 6  // 验证码位数
+	totpPeriod     = 30 // 时间步长（秒）
+	totpSkew       = 1  // 允许前后偏差的时间步数，兼容客户端时钟误差
+	totpSecretSize = 20 // 密钥字节数，与 SHA1 输出长度一致
+)
+
+// totpEncoding 密钥使用不带填充的 Base32 编码，与常见的身份验证器应用兼容
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+var (
+	// ErrTOTPInvalid 验证码错误或已超出允许的时间误差
+	ErrTOTPInvalid = errors.New("验证码错误")
+	// ErrTOTPReused 同一个时间步的验证码已经使用过
+	ErrTOTPReused = errors.New("验证码已使用，请等待下一个验证码")
+)
+
+// GenerateTOTPSecret 生成随机的 TOTP 密钥，返回 Base32 编码
+func GenerateTOTPSecret() (string, error) {
+	buf := make([]byte, totpSecretSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(buf), nil
+}
+
+// TOTPProvisioningURI 生成身份验证器应用扫码使用的 otpauth URI
+func TOTPProvisioningURI(secret, issuer, account string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// HOTP 按 RFC 4226 计算基于计数器的一次性密码
+func HOTP(key []byte, counter uint64, digits int, h func() hash.Hash) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(h, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// 动态截断
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+// TOTP 按 RFC 6238 计算 t 时刻的一次性密码
+func TOTP(key []byte, t time.Time, period int64, digits int, h func() hash.Hash) string {
+	return HOTP(key, uint64(t.Unix()/period), digits, h)
+}
+
+// ValidateTOTP 校验 6 位 SHA1 验证码，允许前后一个时间步长的误差，返回匹配的时间步
+func ValidateTOTP(secret, code string, t time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+	step := t.Unix() / totpPeriod
+	for i := -totpSkew; i <= totpSkew; i++ {
+		candidate := HOTP(key, uint64(step+int64(i)), totpDigits, sha1.New)
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(code)) == 1 {
+			return step + int64(i), true
+		}
+	}
+	return 0, false
+}
+
+// ValidateTOTPOnce 校验验证码，并通过 markUsed 记录匹配的时间步，保证同一个时间步的验证码只能使用一次。
+// markUsed 返回 false 表示该时间步已被使用过
+func ValidateTOTPOnce(secret, code string, t time.Time, markUsed func(step int64) (bool, error)) error {
+	step, ok := ValidateTOTP(secret, code, t)
+	if !ok {
+		return ErrTOTPInvalid
+	}
+	fresh, err := markUsed(step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrTOTPReused
+	}
+	return nil
+}
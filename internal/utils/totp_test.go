@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"hash"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 的测试密钥
+var (
+	rfcSeedSHA1   = []byte("12345678901234567890")
+	rfcSeedSHA256 = []byte("12345678901234567890123456789012")
+	rfcSeedSHA512 = []byte("1234567890123456789012345678901234567890123456789012345678901234")
+)
+
+func TestTOTPRFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix   int64
+		sha1   string
+		sha256 string
+		sha512 string
+	}{
+		{59, "94287082", "46119246", "90693936"},
+		{1111111109, "07081804", "68084774", "25091201"},
+		{1111111111, "14050471", "67062674", "99943326"},
+		{1234567890, "89005924", "91819424", "93441116"},
+		{2000000000, "69279037", "90698825", "38618901"},
+		{20000000000, "65353130", "77737706", "47863826"},
+	}
+	algorithms := []struct {
+		name string
+		seed []byte
+		h    func() hash.Hash
+		want func(int) string
+	}{
+		{"SHA1", rfcSeedSHA1, sha1.New, func(i int) string { return tests[i].sha1 }},
+		{"SHA256", rfcSeedSHA256, sha256.New, func(i int) string { return tests[i].sha256 }},
+		{"SHA512", rfcSeedSHA512, sha512.New, func(i int) string { return tests[i].sha512 }},
+	}
+	for _, alg := range algorithms {
+		for i, tt := range tests {
+			got := TOTP(alg.seed, time.Unix(tt.unix, 0), 30, 8, alg.h)
+			if want := alg.want(i); got != want {
+				t.Errorf("%s TOTP(t=%d) = %s, want %s", alg.name, tt.unix, got, want)
+			}
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	secret := totpEncoding.EncodeToString(rfcSeedSHA1)
+	now := time.Unix(1234567890, 0)
+	step := now.Unix() / totpPeriod
+
+	tests := []struct {
+		offset int64 // 验证码所在时间步与当前时间步的差
+		ok     bool
+	}{
+		{-2, false},
+		{-1, true},
+		{0, true},
+		{1, true},
+		{2, false},
+	}
+	for _, tt := range tests {
+		code := TOTP(rfcSeedSHA1, now.Add(time.Duration(tt.offset*totpPeriod)*time.Second), totpPeriod, totpDigits, sha1.New)
+		gotStep, ok := ValidateTOTP(secret, code, now)
+		if ok != tt.ok {
+			t.Errorf("offset %d: ValidateTOTP ok = %v, want %v", tt.offset, ok, tt.ok)
+			continue
+		}
+		if ok && gotStep != step+tt.offset {
+			t.Errorf("offset %d: ValidateTOTP step = %d, want %d", tt.offset, gotStep, step+tt.offset)
+		}
+	}
+}
+
+func TestValidateTOTPRejectsMalformed(t *testing.T) {
+	secret := totpEncoding.EncodeToString(rfcSeedSHA1)
+	now := time.Unix(1234567890, 0)
+	code := TOTP(rfcSeedSHA1, now, totpPeriod, totpDigits, sha1.New)
+
+	for _, tt := range []struct{ secret, code string }{
+		{secret, ""},
+		{secret, code[:5]},
+		{secret, code + "0"},
+		{"not base32!", code},
+	} {
+		if _, ok := ValidateTOTP(tt.secret, tt.code, now); ok {
+			t.Errorf("ValidateTOTP(%q, %q) accepted", tt.secret, tt.code)
+		}
+	}
+}
+
+func TestValidateTOTPOnceRejectsReplay(t *testing.T) {
+	secret := totpEncoding.EncodeToString(rfcSeedSHA1)
+	now := time.Unix(1234567890, 0)
+	used := map[int64]bool{}
+	markUsed := func(step int64) (bool, error) {
+		if used[step] {
+			return false, nil
+		}
+		used[step] = true
+		return true, nil
+	}
+
+	code := TOTP(rfcSeedSHA1, now, totpPeriod, totpDigits, sha1.New)
+	if err := ValidateTOTPOnce(secret, code, now, markUsed); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if err := ValidateTOTPOnce(secret, code, now, markUsed); !errors.Is(err, ErrTOTPReused) {
+		t.Fatalf("replay in same step: err = %v, want ErrTOTPReused", err)
+	}
+	// 下一个时间步内该验证码仍在允许误差内，但所在时间步已经使用过
+	if err := ValidateTOTPOnce(secret, code, now.Add(totpPeriod*time.Second), markUsed); !errors.Is(err, ErrTOTPReused) {
+		t.Fatalf("replay in next step: err = %v, want ErrTOTPReused", err)
+	}
+
+	next := TOTP(rfcSeedSHA1, now.Add(totpPeriod*time.Second), totpPeriod, totpDigits, sha1.New)
+	if err := ValidateTOTPOnce(secret, next, now.Add(totpPeriod*time.Second), markUsed); err != nil {
+		t.Fatalf("next code: %v", err)
+	}
+	stale := TOTP(rfcSeedSHA1, now.Add(-5*totpPeriod*time.Second), totpPeriod, totpDigits, sha1.New)
+	if err := ValidateTOTPOnce(secret, stale, now, markUsed); !errors.Is(err, ErrTOTPInvalid) {
+		t.Fatalf("stale code: err = %v, want ErrTOTPInvalid", err)
+	}
+}
+
+func TestValidateTOTPOnceStoreError(t *testing.T) {
+	secret := totpEncoding.EncodeToString(rfcSeedSHA1)
+	now := time.Unix(1234567890, 0)
+	code := TOTP(rfcSeedSHA1, now, totpPeriod, totpDigits, sha1.New)
+	storeErr := errors.New("redis unavailable")
+
+	err := ValidateTOTPOnce(secret, code, now, func(int64) (bool, error) { return false, storeErr })
+	if !errors.Is(err, storeErr) {
+		t.Fatalf("err = %v, want %v", err, storeErr)
+	}
+}
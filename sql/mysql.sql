@@ -130,4 +130,192 @@ CREATE TABLE system_logs (
 # 新增的字段
 ALTER TABLE `groups`
     ADD COLUMN `announcement` TEXT COMMENT '群公告，允许为空' AFTER `group_avatar`,
-    ADD COLUMN `description` TEXT COMMENT '群描述，允许为空' AFTER `announcement`;
\ No newline at end of file
+    ADD COLUMN `description` TEXT COMMENT '群描述，允许为空' AFTER `announcement`;
+
+-- 群邀请链接表：存储群主或管理员生成的邀请链接
+CREATE TABLE group_invite_links (
+                                    id INT AUTO_INCREMENT PRIMARY KEY COMMENT '邀请链接ID，自增主键',
+                                    group_id INT NOT NULL COMMENT '群组ID，不能为空',
+                                    creator_id INT NOT NULL COMMENT '创建者的用户ID，不能为空',
+                                    code VARCHAR(32) NOT NULL UNIQUE COMMENT '邀请码，唯一，不能为空',
+                                    expires_at TIMESTAMP NULL DEFAULT NULL COMMENT '过期时间，为空表示永不过期',
+                                    max_uses INT NOT NULL DEFAULT 0 COMMENT '最大使用次数，0表示不限制',
+                                    used_count INT NOT NULL DEFAULT 0 COMMENT '已使用次数',
+                                    revoked BOOLEAN DEFAULT FALSE COMMENT '是否已撤销',
+                                    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '记录创建时间，默认为当前时间',
+                                    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '记录更新时间，在更新时自动设置为当前时间',
+                                    FOREIGN KEY (group_id) REFERENCES `groups`(id) ,
+                                    FOREIGN KEY (creator_id) REFERENCES users(id)
+) COMMENT='群邀请链接表';
+
+-- 群邀请链接使用记录表：记录谁通过哪个链接加入了群聊
+CREATE TABLE group_invite_link_usages (
+                                          id INT AUTO_INCREMENT PRIMARY KEY COMMENT '使用记录ID，自增主键',
+                                          link_id INT NOT NULL COMMENT '邀请链接ID，不能为空',
+                                          group_id INT NOT NULL COMMENT '群组ID，不能为空',
+                                          user_id INT NOT NULL COMMENT '入群用户ID，不能为空',
+                                          created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '入群时间，默认为当前时间',
+                                          FOREIGN KEY (link_id) REFERENCES group_invite_links(id) ,
+                                          FOREIGN KEY (group_id) REFERENCES `groups`(id) ,
+                                          FOREIGN KEY (user_id) REFERENCES users(id)
+) COMMENT='群邀请链接使用记录表';
+
+-- 群成员上限：群等级决定默认上限，max_members 为单群覆盖值
+ALTER TABLE `groups`
+    ADD COLUMN `tier` VARCHAR(32) NOT NULL DEFAULT 'normal' COMMENT '群等级，决定默认的成员上限' AFTER `description`,
+    ADD COLUMN `max_members` INT NOT NULL DEFAULT 0 COMMENT '单群成员上限覆盖值，0表示使用群等级的上限' AFTER `tier`;
+
+-- 群分类：由群主或管理员编辑
+ALTER TABLE `groups`
+    ADD COLUMN `category` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '群分类，允许为空' AFTER `max_members`;
+
+-- 群公告表：每次发布都是一条新的公告，保留历史
+CREATE TABLE group_announcements (
+                                     id INT AUTO_INCREMENT PRIMARY KEY COMMENT '公告ID，自增主键',
+                                     group_id INT NOT NULL COMMENT '群组ID，不能为空',
+                                     author_id INT NOT NULL COMMENT '发布者的用户ID，不能为空',
+                                     version INT NOT NULL COMMENT '公告版本号，同一个群内递增',
+                                     content TEXT COMMENT '公告内容',
+                                     pinned BOOLEAN DEFAULT FALSE COMMENT '是否置顶',
+                                     require_ack BOOLEAN DEFAULT FALSE COMMENT '是否需要成员确认已读',
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '记录创建时间，默认为当前时间',
+                                     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '记录更新时间，在更新时自动设置为当前时间',
+                                     UNIQUE KEY uk_group_version (group_id, version),
+                                     FOREIGN KEY (group_id) REFERENCES `groups`(id) ,
+                                     FOREIGN KEY (author_id) REFERENCES users(id)
+) COMMENT='群公告表';
+
+-- 群公告确认表：记录成员确认已读的公告
+CREATE TABLE group_announcement_acks (
+                                         id INT AUTO_INCREMENT PRIMARY KEY COMMENT '确认记录ID，自增主键',
+                                         announcement_id INT NOT NULL COMMENT '公告ID，不能为空',
+                                         user_id INT NOT NULL COMMENT '确认的用户ID，不能为空',
+                                         created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '确认时间，默认为当前时间',
+                                         UNIQUE KEY uk_announcement_user (announcement_id, user_id),
+                                         FOREIGN KEY (announcement_id) REFERENCES group_announcements(id) ,
+                                         FOREIGN KEY (user_id) REFERENCES users(id)
+) COMMENT='群公告确认表';
+
+-- 群组审计日志：群管理操作记录在系统日志表中，log_type 为操作类型
+ALTER TABLE system_logs
+    ADD COLUMN `group_id` INT NOT NULL DEFAULT 0 COMMENT '群组ID，非群组日志为0' AFTER `message`,
+    ADD COLUMN `actor_id` INT NOT NULL DEFAULT 0 COMMENT '操作者的用户ID' AFTER `group_id`,
+    ADD COLUMN `target_id` INT NOT NULL DEFAULT 0 COMMENT '被操作的用户ID，没有时为0' AFTER `actor_id`,
+    ADD COLUMN `before_value` TEXT COMMENT '操作前的值(JSON)' AFTER `target_id`,
+    ADD COLUMN `after_value` TEXT COMMENT '操作后的值(JSON)' AFTER `before_value`,
+    ADD INDEX idx_group_id (group_id);
+
+-- 群成员变动的系统消息：入群、被移出、退出群聊时写入群聊时间线
+ALTER TABLE messages
+    MODIFY COLUMN message_type ENUM('text', 'image', 'video', 'file', 'system') DEFAULT 'text' COMMENT '消息类型，默认为text，system为系统消息';
+
+-- 群组相关的通知：被邀请入群、被移出群聊
+ALTER TABLE notifications
+    MODIFY COLUMN type ENUM('message', 'friend_request', 'group_request', 'rejected', 'group_invite', 'group_removed', 'other') DEFAULT 'message' COMMENT '通知类型，默认为message';
+
+-- 通知支持软删除和按时间倒序的游标分页
+ALTER TABLE notifications
+    ADD COLUMN `deleted_at` TIMESTAMP NULL DEFAULT NULL COMMENT '删除时间，用户删除通知时软删除' AFTER `updated_at`,
+    ADD INDEX idx_deleted_at (deleted_at),
+    ADD INDEX idx_receiver_created (receiver_id, created_at, id);
+
+-- 好友请求去重：同一对用户只保留一条好友关系记录，被拒绝或过期后重新请求复用原记录
+-- 先删除已有的重复记录，每对用户只保留 id 最大（最新）的一条，否则无法添加唯一索引
+DELETE older FROM friendships older
+    JOIN friendships newer
+        ON newer.user_id = older.user_id AND newer.friend_id = older.friend_id AND newer.id > older.id;
+
+ALTER TABLE friendships
+    ADD UNIQUE KEY uk_user_friend (user_id, friend_id);
+
+-- 好友请求过期后通知状态为 expired
+ALTER TABLE notifications
+    MODIFY COLUMN status ENUM('pending', 'accepted', 'rejected', 'expired') DEFAULT 'pending' COMMENT '通知状态，默认为pending';
+
+-- 好友分组支持排序，同一个用户下分组名称不能重复
+-- 先合并已有的重名分组：好友移动到同名分组中 id 最小的一个，再删除其余分组，否则无法添加唯一索引
+UPDATE friendships f
+    JOIN friend_groups dup ON dup.id = f.group_id
+    JOIN (SELECT user_id, group_name, MIN(id) AS keep_id
+          FROM friend_groups
+          GROUP BY user_id, group_name
+          HAVING COUNT(*) > 1) keep
+        ON keep.user_id = dup.user_id AND keep.group_name = dup.group_name AND keep.keep_id <> dup.id
+SET f.group_id = keep.keep_id;
+
+DELETE dup FROM friend_groups dup
+    JOIN friend_groups keep
+        ON keep.user_id = dup.user_id AND keep.group_name = dup.group_name AND keep.id < dup.id;
+
+ALTER TABLE friend_groups
+    ADD COLUMN `sort_order` INT NOT NULL DEFAULT 0 COMMENT '排序，越小越靠前' AFTER `group_name`,
+    ADD UNIQUE KEY uk_user_group_name (user_id, group_name);
+
+-- 好友个性化设置：特别关心、消息免打扰、置顶会话、隐藏在线状态
+ALTER TABLE friendships
+    ADD COLUMN `starred` BOOLEAN NOT NULL DEFAULT FALSE COMMENT '是否特别关心' AFTER `remark`,
+    ADD COLUMN `muted` BOOLEAN NOT NULL DEFAULT FALSE COMMENT '是否消息免打扰' AFTER `starred`,
+    ADD COLUMN `pinned` BOOLEAN NOT NULL DEFAULT FALSE COMMENT '是否置顶会话' AFTER `muted`,
+    ADD COLUMN `hide_presence` BOOLEAN NOT NULL DEFAULT FALSE COMMENT '是否对该好友隐藏在线状态' AFTER `pinned`;
+
+-- 好友关系状态机：拒绝和删除不再物理删除记录，而是切换状态
+ALTER TABLE friendships
+    MODIFY COLUMN status ENUM('pending', 'accepted', 'rejected', 'deleted', 'blocked') DEFAULT 'pending' COMMENT '好友关系状态，默认为pending';
+
+-- 支持通过邮箱或账号名登录：邮箱改为可空，新增唯一的账号名
+ALTER TABLE users
+    MODIFY COLUMN email VARCHAR(255) NULL COMMENT '用户邮箱，唯一，未填写时为 NULL',
+    ADD COLUMN `handle` VARCHAR(32) NULL DEFAULT NULL COMMENT '用户账号名，唯一，可用于登录，未设置时为 NULL' AFTER `email`,
+    ADD UNIQUE KEY uk_handle (handle);
+
+-- 清理注册时自动生成的占位邮箱 <username>@imSystem.com，避免占用用户真实的邮箱
+UPDATE users SET email = NULL WHERE email LIKE '%@imSystem.com';
+
+-- 两步验证：用户的 TOTP 密钥和开启状态
+ALTER TABLE users
+    ADD COLUMN `totp_secret` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '两步验证密钥（Base32），未开启时可能是待确认的密钥' AFTER `date_of_birth`,
+    ADD COLUMN `totp_enabled` BOOLEAN NOT NULL DEFAULT FALSE COMMENT '是否已开启两步验证' AFTER `totp_secret`;
+
+-- 两步验证恢复码表：丢失身份验证器时代替验证码使用，每个只能使用一次
+CREATE TABLE user_recovery_codes (
+                                     id INT AUTO_INCREMENT PRIMARY KEY COMMENT '恢复码ID，自增主键',
+                                     user_id INT NOT NULL COMMENT '用户ID',
+                                     code_hash CHAR(64) NOT NULL COMMENT '恢复码的 SHA-256 哈希',
+                                     used_at TIMESTAMP NULL DEFAULT NULL COMMENT '使用时间，未使用时为 NULL',
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+                                     INDEX idx_user_id (user_id),
+                                     FOREIGN KEY (user_id) REFERENCES users(id)
+) COMMENT='两步验证恢复码表';
+
+-- 系统角色：管理员可以访问 /im-server/admin 下的运维接口
+ALTER TABLE users
+    ADD COLUMN `role` ENUM('user', 'admin') NOT NULL DEFAULT 'user' COMMENT '系统角色' AFTER `totp_enabled`;
+
+-- 群成员去重：同一个用户在同一个群中只能有一条成员记录，防止并发入群重复插入
+-- 先删除已有的重复记录，每个用户只保留最早加入的一条
+DELETE newer FROM group_members newer
+    JOIN group_members older
+        ON older.group_id = newer.group_id AND older.user_id = newer.user_id AND older.id < newer.id;
+
+ALTER TABLE group_members
+    ADD UNIQUE KEY uk_group_user (group_id, user_id);
+
+-- 群成员发言统计：发言时累加计数，计算等级时不再统计消息表
+ALTER TABLE group_members
+    ADD COLUMN `message_count` INT NOT NULL DEFAULT 0 COMMENT '发言数，不包含系统消息' AFTER `nickname`,
+    ADD COLUMN `active_days` INT NOT NULL DEFAULT 0 COMMENT '有发言的天数，不包含系统消息' AFTER `message_count`,
+    ADD COLUMN `last_active_date` DATE NULL DEFAULT NULL COMMENT '最近一次发言的日期' AFTER `active_days`;
+
+-- 根据已有的群消息回填发言统计
+UPDATE group_members gm
+    JOIN (SELECT receiver_group_id, sender_id,
+                 COUNT(*) AS message_count,
+                 COUNT(DISTINCT DATE(created_at)) AS active_days,
+                 MAX(DATE(created_at)) AS last_active_date
+          FROM messages
+          WHERE receiver_group_id IS NOT NULL AND message_type <> 'system'
+          GROUP BY receiver_group_id, sender_id) stats
+        ON stats.receiver_group_id = gm.group_id AND stats.sender_id = gm.user_id
+SET gm.message_count = stats.message_count,
+    gm.active_days = stats.active_days,
+    gm.last_active_date = stats.last_active_date;